@@ -0,0 +1,378 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"jv/internal/lockfile"
+)
+
+const (
+	// indexLockName guards index.json against concurrent jv processes
+	indexLockName = "index.json.lock"
+
+	// indexLockWait is how long to wait for another jv process to release
+	// the index, which it only holds while updating it
+	indexLockWait = 30 * time.Second
+)
+
+// Entry describes a verified archive stored in the cache
+type Entry struct {
+	Checksum     string    `json:"checksum"`
+	ChecksumAlgo string    `json:"checksum_algo"`
+	FileName     string    `json:"file_name"`
+	URL          string    `json:"url"`
+	Distributor  string    `json:"distributor"`
+	Version      string    `json:"version"`
+	ImageType    string    `json:"image_type"`
+	Arch         string    `json:"arch"`
+	Size         int64     `json:"size"`
+	AddedAt      time.Time `json:"added_at"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+// Cache is a directory of downloaded archives indexed by checksum
+type Cache struct {
+	dir     string
+	entries []Entry
+}
+
+// Open loads the archive cache index, creating an empty cache if none exists
+func Open() (*Cache, error) {
+	c := &Cache{dir: getCacheDir()}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the index from disk, replacing the entries in memory
+func (c *Cache) load() error {
+	c.entries = make([]Entry, 0)
+
+	data, err := os.ReadFile(c.indexPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return fmt.Errorf("corrupted cache index %s: %w", c.indexPath(), err)
+	}
+	return nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Entries returns all cached archives, newest first
+func (c *Cache) Entries() []Entry {
+	entries := make([]Entry, len(c.entries))
+	copy(entries, c.entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt.After(entries[j].AddedAt)
+	})
+	return entries
+}
+
+// Path returns the location of an entry's archive on disk
+func (c *Cache) Path(e Entry) string {
+	return filepath.Join(c.dir, strings.ToLower(e.Checksum), e.FileName)
+}
+
+// Contains reports whether path lies inside the cache directory
+func (c *Cache) Contains(path string) bool {
+	rel, err := filepath.Rel(c.dir, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// Lookup returns the entry with the given checksum if its archive is present
+func (c *Cache) Lookup(checksum string) *Entry {
+	if checksum == "" {
+		return nil
+	}
+
+	for i := range c.entries {
+		if strings.EqualFold(c.entries[i].Checksum, checksum) {
+			if _, err := os.Stat(c.Path(c.entries[i])); err != nil {
+				return nil
+			}
+			e := c.entries[i]
+			return &e
+		}
+	}
+	return nil
+}
+
+// Find returns the newest present entry matching distributor, version, image type and arch
+func (c *Cache) Find(distributor, version, imageType, arch string) *Entry {
+	var best *Entry
+	for i := range c.entries {
+		e := c.entries[i]
+		if !strings.EqualFold(e.Distributor, distributor) || e.Version != version ||
+			e.ImageType != imageType || e.Arch != arch {
+			continue
+		}
+		if _, err := os.Stat(c.Path(e)); err != nil {
+			continue
+		}
+		if best == nil || e.AddedAt.After(best.AddedAt) {
+			best = &e
+		}
+	}
+	return best
+}
+
+// Add moves the archive at srcPath into the cache and records it.
+// It returns the archive's new location.
+func (c *Cache) Add(srcPath string, e Entry) (string, error) {
+	if e.Checksum == "" {
+		return "", fmt.Errorf("cannot cache an archive without a checksum")
+	}
+	e.Checksum = strings.ToLower(e.Checksum)
+
+	dest := c.Path(e)
+	err := c.update(func() error {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create cache directory: %w", err)
+		}
+
+		if err := moveFile(srcPath, dest); err != nil {
+			return fmt.Errorf("failed to store archive in cache: %w", err)
+		}
+
+		now := time.Now()
+		e.AddedAt = now
+		e.LastUsed = now
+		if info, err := os.Stat(dest); err == nil {
+			e.Size = info.Size()
+		}
+
+		c.removeEntry(e.Checksum)
+		c.entries = append(c.entries, e)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// Touch records that an entry was just used
+func (c *Cache) Touch(checksum string) error {
+	return c.update(func() error {
+		for i := range c.entries {
+			if strings.EqualFold(c.entries[i].Checksum, checksum) {
+				c.entries[i].LastUsed = time.Now()
+			}
+		}
+		return nil
+	})
+}
+
+// Remove deletes an entry and its archive
+func (c *Cache) Remove(checksum string) error {
+	return c.update(func() error {
+		for _, e := range c.entries {
+			if strings.EqualFold(e.Checksum, checksum) {
+				if err := os.RemoveAll(filepath.Dir(c.Path(e))); err != nil {
+					return err
+				}
+			}
+		}
+		c.removeEntry(checksum)
+		return nil
+	})
+}
+
+// Clean deletes every cached archive and returns the number of bytes freed
+func (c *Cache) Clean() (int64, error) {
+	var freed int64
+	err := c.update(func() error {
+		for i, e := range c.entries {
+			if info, err := os.Stat(c.Path(e)); err == nil {
+				freed += info.Size()
+			}
+			if err := os.RemoveAll(filepath.Dir(c.Path(e))); err != nil {
+				c.entries = c.entries[i:]
+				return err
+			}
+		}
+
+		c.entries = make([]Entry, 0)
+		return nil
+	})
+	return freed, err
+}
+
+// Prune removes entries whose archive is missing and archives superseded by a
+// newer download of the same distributor, version, image type and arch.
+// It returns the removed entries.
+func (c *Cache) Prune() ([]Entry, error) {
+	var removed []Entry
+	err := c.update(func() error {
+		newest := make(map[string]Entry)
+		for _, e := range c.entries {
+			if _, err := os.Stat(c.Path(e)); err != nil {
+				continue
+			}
+			key := pruneKey(e)
+			if cur, ok := newest[key]; !ok || e.AddedAt.After(cur.AddedAt) {
+				newest[key] = e
+			}
+		}
+
+		kept := make([]Entry, 0, len(newest))
+		for i, e := range c.entries {
+			if keep, ok := newest[pruneKey(e)]; ok && strings.EqualFold(keep.Checksum, e.Checksum) {
+				kept = append(kept, e)
+				continue
+			}
+			if err := os.RemoveAll(filepath.Dir(c.Path(e))); err != nil {
+				c.entries = append(kept, c.entries[i:]...)
+				return err
+			}
+			removed = append(removed, e)
+		}
+
+		c.entries = kept
+		return nil
+	})
+	return removed, err
+}
+
+func pruneKey(e Entry) string {
+	return strings.ToLower(strings.Join([]string{e.Distributor, e.Version, e.ImageType, e.Arch}, "|"))
+}
+
+func (c *Cache) removeEntry(checksum string) {
+	for i, e := range c.entries {
+		if strings.EqualFold(e.Checksum, checksum) {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.dir, "index.json")
+}
+
+// update applies mutate to the index while holding the index lock. The
+// index is read again first, so changes other jv processes saved since Open
+// are kept, and saved even when mutate fails part way through.
+func (c *Cache) update(mutate func() error) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	lock, err := c.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := c.load(); err != nil {
+		return err
+	}
+	mutateErr := mutate()
+	return errors.Join(mutateErr, c.save())
+}
+
+// save writes the index to a temp file and renames it over index.json, so
+// readers never see a partly written index
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "index-*.json.tmp")
+	if err != nil {
+		return err
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if err := errors.Join(werr, cerr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.indexPath()); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// lockIndex takes the index lock, waiting while another live jv process holds
+// it. Locks left by dead processes are taken over.
+func (c *Cache) lockIndex() (*lockfile.Lock, error) {
+	lockPath := filepath.Join(c.dir, indexLockName)
+	lock, err := lockfile.Acquire(lockPath, indexLockWait, nil)
+	if errors.Is(err, lockfile.ErrLocked) {
+		return nil, fmt.Errorf("the cache index is %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock the cache index: %w", err)
+	}
+	return lock, nil
+}
+
+// moveFile renames src to dst, copying when they are on different volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
+
+// getCacheDir returns the archive cache directory
+// Following XDG Base Directory specification
+func getCacheDir() string {
+	// Try XDG_CACHE_HOME first (standard on Unix systems)
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome != "" {
+		return filepath.Join(cacheHome, "jv", "archives")
+	}
+
+	// Fallback to $HOME/.cache/jv/archives (XDG default)
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+
+	return filepath.Join(homeDir, ".cache", "jv", "archives")
+}
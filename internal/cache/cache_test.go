@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache in a temp directory holding the given
+// entries, with an archive of size bytes for each one not listed in missing
+func newTestCache(t *testing.T, entries []Entry, missing ...string) *Cache {
+	t.Helper()
+	c := &Cache{dir: t.TempDir(), entries: entries}
+	for _, e := range entries {
+		if contains(missing, e.Checksum) {
+			continue
+		}
+		path := c.Path(e)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, e.Size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.save(); err != nil {
+		t.Fatalf("save(): %v", err)
+	}
+	return c
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// checksums returns the sorted checksums of entries
+func checksums(entries []Entry) []string {
+	var sums []string
+	for _, e := range entries {
+		sums = append(sums, e.Checksum)
+	}
+	sort.Strings(sums)
+	return sums
+}
+
+// reopen loads the index a cache saved, as another jv process would
+func reopen(t *testing.T, c *Cache) *Cache {
+	t.Helper()
+	other := &Cache{dir: c.dir}
+	if err := other.load(); err != nil {
+		t.Fatalf("load(): %v", err)
+	}
+	return other
+}
+
+func TestClean(t *testing.T) {
+	c := newTestCache(t, []Entry{
+		{Checksum: "aa", FileName: "jdk-21.zip", Distributor: "Eclipse Adoptium", Version: "21", Size: 300},
+		{Checksum: "bb", FileName: "jdk-17.zip", Distributor: "Eclipse Adoptium", Version: "17", Size: 200},
+		{Checksum: "cc", FileName: "jdk-11.zip", Distributor: "Eclipse Adoptium", Version: "11", Size: 100},
+	}, "cc")
+
+	freed, err := c.Clean()
+	if err != nil {
+		t.Fatalf("Clean(): %v", err)
+	}
+	if freed != 500 {
+		t.Errorf("Clean() freed %d bytes, want 500", freed)
+	}
+	if len(c.Entries()) != 0 {
+		t.Errorf("entries after Clean() = %v, want none", checksums(c.Entries()))
+	}
+	if len(reopen(t, c).Entries()) != 0 {
+		t.Error("the saved index still lists entries after Clean()")
+	}
+	for _, sum := range []string{"aa", "bb"} {
+		if _, err := os.Stat(filepath.Join(c.dir, sum)); !os.IsNotExist(err) {
+			t.Errorf("archive directory %s still exists after Clean(): %v", sum, err)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Now()
+	c := newTestCache(t, []Entry{
+		// Superseded by a newer download of the same release
+		{Checksum: "old", FileName: "jdk-21.0.4.zip", Distributor: "Eclipse Adoptium", Version: "21", ImageType: "jdk", Arch: "x64", AddedAt: now.Add(-2 * day)},
+		{Checksum: "new", FileName: "jdk-21.0.5.zip", Distributor: "Eclipse Adoptium", Version: "21", ImageType: "jdk", Arch: "x64", AddedAt: now.Add(-day)},
+		// Same version, other package type and architecture
+		{Checksum: "jre", FileName: "jre-21.zip", Distributor: "Eclipse Adoptium", Version: "21", ImageType: "jre", Arch: "x64", AddedAt: now.Add(-3 * day)},
+		{Checksum: "x86", FileName: "jdk-21-x86.zip", Distributor: "Eclipse Adoptium", Version: "21", ImageType: "jdk", Arch: "x86", AddedAt: now.Add(-3 * day)},
+		// Distributor names are compared case-insensitively
+		{Checksum: "case", FileName: "jdk-21.0.3.zip", Distributor: "eclipse adoptium", Version: "21", ImageType: "jdk", Arch: "x64", AddedAt: now.Add(-3 * day)},
+		// Archive deleted by hand; a newer entry without an archive does
+		// not supersede one that is present
+		{Checksum: "gone", FileName: "jdk-17.zip", Distributor: "Eclipse Adoptium", Version: "17", ImageType: "jdk", Arch: "x64", AddedAt: now},
+		{Checksum: "17", FileName: "jdk-17.0.1.zip", Distributor: "Eclipse Adoptium", Version: "17", ImageType: "jdk", Arch: "x64", AddedAt: now.Add(-day)},
+	}, "gone")
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatalf("Prune(): %v", err)
+	}
+
+	wantRemoved := []string{"case", "gone", "old"}
+	if got := checksums(removed); strings.Join(got, ",") != strings.Join(wantRemoved, ",") {
+		t.Errorf("Prune() removed %v, want %v", got, wantRemoved)
+	}
+	wantKept := []string{"17", "jre", "new", "x86"}
+	if got := checksums(reopen(t, c).Entries()); strings.Join(got, ",") != strings.Join(wantKept, ",") {
+		t.Errorf("saved index after Prune() = %v, want %v", got, wantKept)
+	}
+	for _, sum := range wantRemoved {
+		if _, err := os.Stat(filepath.Join(c.dir, sum)); !os.IsNotExist(err) {
+			t.Errorf("archive directory %s still exists after Prune(): %v", sum, err)
+		}
+	}
+	for _, sum := range wantKept {
+		if _, err := os.Stat(filepath.Join(c.dir, sum)); err != nil {
+			t.Errorf("kept archive directory %s: %v", sum, err)
+		}
+	}
+}
+
+func TestUpdateKeepsChangesOfOtherProcesses(t *testing.T) {
+	first := newTestCache(t, nil)
+	second := reopen(t, first)
+
+	src := filepath.Join(t.TempDir(), "jdk-21.zip")
+	if err := os.WriteFile(src, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Add(src, Entry{Checksum: "AA", FileName: "jdk-21.zip", Version: "21"}); err != nil {
+		t.Fatalf("Add(): %v", err)
+	}
+
+	// second loaded the index before the archive was added
+	src = filepath.Join(t.TempDir(), "jdk-17.zip")
+	if err := os.WriteFile(src, []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Add(src, Entry{Checksum: "bb", FileName: "jdk-17.zip", Version: "17"}); err != nil {
+		t.Fatalf("Add(): %v", err)
+	}
+
+	want := []string{"aa", "bb"}
+	if got := checksums(reopen(t, first).Entries()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("saved index = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateSavesPartialChanges(t *testing.T) {
+	c := newTestCache(t, []Entry{
+		{Checksum: "aa", FileName: "jdk-21.zip"},
+		{Checksum: "bb", FileName: "jdk-17.zip"},
+	})
+
+	failure := errors.New("disk full")
+	err := c.update(func() error {
+		c.removeEntry("aa")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("update() error = %v, want %v", err, failure)
+	}
+
+	want := []string{"bb"}
+	if got := checksums(reopen(t, c).Entries()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("saved index = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(c.dir, indexLockName)); !os.IsNotExist(err) {
+		t.Errorf("index lock not released: %v", err)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(c.dir, "*.tmp")); len(tmps) != 0 {
+		t.Errorf("temp files left behind: %v", tmps)
+	}
+}
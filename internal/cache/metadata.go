@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MetadataInfo describes when a cached metadata value was fetched and how the
+// server that sent it allows it to be reused
+type MetadataInfo struct {
+	StoredAt time.Time `json:"stored_at"`
+	ETag     string    `json:"etag,omitempty"`    // Validator for conditional requests
+	Expires  time.Time `json:"expires,omitempty"` // From Cache-Control max-age; zero if it must be revalidated
+}
+
+// Fresh reports whether the value may be used without asking the server
+func (m MetadataInfo) Fresh() bool {
+	return !m.Expires.IsZero() && time.Now().Before(m.Expires)
+}
+
+// metadataDocument wraps a cached metadata value with its MetadataInfo
+type metadataDocument struct {
+	MetadataInfo
+	Data json.RawMessage `json:"data"`
+}
+
+// LoadMetadata reads the metadata stored under key into v and returns when and
+// how it was fetched. Keys are slash-separated, e.g.
+// "release-notes/adoptium/21.0.5+11".
+func LoadMetadata(key string, v any) (MetadataInfo, error) {
+	data, err := os.ReadFile(metadataPath(key))
+	if err != nil {
+		return MetadataInfo{}, err
+	}
+
+	var doc metadataDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return MetadataInfo{}, err
+	}
+	if err := json.Unmarshal(doc.Data, v); err != nil {
+		return MetadataInfo{}, err
+	}
+	return doc.MetadataInfo, nil
+}
+
+// StoreMetadata stores v as JSON under key. The time it was stored defaults
+// to now.
+func StoreMetadata(key string, v any, info MetadataInfo) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if info.StoredAt.IsZero() {
+		info.StoredAt = time.Now()
+	}
+	data, err := json.MarshalIndent(metadataDocument{MetadataInfo: info, Data: raw}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := metadataPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// metadataPath maps a key to a file below the metadata directory, replacing
+// characters Windows does not allow in file names
+func metadataPath(key string) string {
+	replacer := strings.NewReplacer(":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_", "\\", "_")
+	parts := strings.Split(strings.ToLower(key), "/")
+	for idx, part := range parts {
+		parts[idx] = replacer.Replace(part)
+		if parts[idx] == "" || parts[idx] == "." || parts[idx] == ".." {
+			parts[idx] = "_"
+		}
+	}
+	return filepath.Join(append([]string{metadataDir()}, parts...)...) + ".json"
+}
+
+// metadataDir holds cached distributor metadata such as version listings and
+// release notes, next to the archive cache
+func metadataDir() string {
+	return filepath.Join(filepath.Dir(getCacheDir()), "metadata")
+}
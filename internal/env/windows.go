@@ -1,8 +1,12 @@
 package env
 
 import (
+	"debug/pe"
+	"errors"
 	"fmt"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 	"unsafe"
@@ -20,6 +24,7 @@ var (
 	user32           = syscall.NewLazyDLL("user32.dll")
 	sendMessageW     = user32.NewProc("SendMessageW")
 	systemEnvRegPath = `System\CurrentControlSet\Control\Session Manager\Environment`
+	userEnvRegPath   = `Environment`
 )
 
 // SetJavaHome sets the JAVA_HOME environment variable system-wide
@@ -126,6 +131,94 @@ func GetJavaHome() (string, error) {
 	return value, nil
 }
 
+// GetPathToFX returns the PATH_TO_FX environment variable, which points
+// JavaFX builds at an OpenJFX SDK's lib folder. The user variable wins over
+// the system one, as it does in new processes.
+func GetPathToFX() (string, error) {
+	for _, location := range []struct {
+		root registry.Key
+		path string
+	}{
+		{registry.CURRENT_USER, userEnvRegPath},
+		{registry.LOCAL_MACHINE, systemEnvRegPath},
+	} {
+		key, err := registry.OpenKey(location.root, location.path, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		value, _, err := key.GetStringValue("PATH_TO_FX")
+		key.Close()
+		if err == nil && value != "" {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("PATH_TO_FX not set")
+}
+
+// SetPathToFX sets PATH_TO_FX system-wide, which requires administrator
+// privileges, or for the current user. Setting it system-wide removes a
+// user variable that would hide it.
+func SetPathToFX(libPath string, systemWide bool) error {
+	libPath = filepath.Clean(libPath)
+
+	root, path := registry.CURRENT_USER, userEnvRegPath
+	if systemWide {
+		root, path = registry.LOCAL_MACHINE, systemEnvRegPath
+	}
+	key, err := registry.OpenKey(root, path, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open registry key (run as administrator): %w", err)
+	}
+	defer key.Close()
+
+	if err := key.SetStringValue("PATH_TO_FX", libPath); err != nil {
+		return fmt.Errorf("failed to set PATH_TO_FX: %w", err)
+	}
+	if systemWide {
+		deleteEnvValue(registry.CURRENT_USER, userEnvRegPath, "PATH_TO_FX")
+	}
+
+	broadcastSettingChange()
+	return nil
+}
+
+// UnsetPathToFX removes PATH_TO_FX from the user and system environment
+func UnsetPathToFX() error {
+	if err := deleteEnvValue(registry.CURRENT_USER, userEnvRegPath, "PATH_TO_FX"); err != nil {
+		return err
+	}
+	if err := deleteEnvValue(registry.LOCAL_MACHINE, systemEnvRegPath, "PATH_TO_FX"); err != nil {
+		return fmt.Errorf("failed to remove system PATH_TO_FX (run as administrator): %w", err)
+	}
+
+	broadcastSettingChange()
+	return nil
+}
+
+// deleteEnvValue removes an environment variable from a registry key; a
+// variable that is not set is not an error
+func deleteEnvValue(root registry.Key, path string, name string) error {
+	key, err := registry.OpenKey(root, path, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		// Without write access the value can only be removed if it is absent
+		readKey, readErr := registry.OpenKey(root, path, registry.QUERY_VALUE)
+		if readErr != nil {
+			return err
+		}
+		defer readKey.Close()
+		if _, _, getErr := readKey.GetStringValue(name); errors.Is(getErr, registry.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer key.Close()
+
+	if err := key.DeleteValue(name); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
 // IsAdmin checks if the current process is running with administrator privileges
 func IsAdmin() bool {
 	var sid *windows.SID
@@ -155,6 +248,181 @@ func IsAdmin() bool {
 	return member
 }
 
+// DiskFree returns the number of bytes available to the current user on the
+// volume that holds path
+func DiskFree(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, &total, &totalFree); err != nil {
+		return 0, fmt.Errorf("failed to query free disk space for %s: %w", path, err)
+	}
+	return free, nil
+}
+
+// ProcessAlive reports whether a process with the given ID is still running
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Access denied still means the process exists
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	const stillActive = 259
+	return code == stillActive
+}
+
+// ProcessJob is a job object holding a process and every process it starts
+type ProcessJob struct {
+	handle windows.Handle
+}
+
+// StartInJob starts cmd inside a new job object, so the programs it launches
+// can be stopped along with it. The process is created suspended and only
+// resumed once it is in the job, so nothing it starts escapes. If no job can
+// be set up the process runs without one and the job is nil. Closing the job
+// leaves its processes running.
+func StartInJob(cmd *exec.Cmd) (*ProcessJob, error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_SUSPENDED
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	pid := uint32(cmd.Process.Pid)
+	job, jobErr := newProcessJob(pid)
+	if err := resumeProcess(pid); err != nil {
+		if jobErr == nil {
+			job.Close()
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if jobErr != nil {
+		return nil, nil
+	}
+	return job, nil
+}
+
+// newProcessJob puts a process into a new job object
+func newProcessJob(pid uint32) (*ProcessJob, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job object: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, pid)
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer windows.CloseHandle(process)
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("failed to assign process %d to a job object: %w", pid, err)
+	}
+	return &ProcessJob{handle: job}, nil
+}
+
+// resumeProcess resumes the threads of a process created suspended. Only its
+// main thread exists at that point.
+func resumeProcess(pid uint32) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return fmt.Errorf("failed to list threads of process %d: %w", pid, err)
+	}
+	defer windows.CloseHandle(snapshot)
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	resumed := false
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != pid {
+			continue
+		}
+		thread, openErr := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if openErr != nil {
+			return fmt.Errorf("failed to open thread of process %d: %w", pid, openErr)
+		}
+		_, resumeErr := windows.ResumeThread(thread)
+		windows.CloseHandle(thread)
+		if resumeErr != nil {
+			return fmt.Errorf("failed to resume process %d: %w", pid, resumeErr)
+		}
+		resumed = true
+	}
+	if !resumed {
+		return fmt.Errorf("failed to resume process %d: no thread found", pid)
+	}
+	return nil
+}
+
+// Terminate kills every process in the job
+func (j *ProcessJob) Terminate() error {
+	return windows.TerminateJobObject(j.handle, 1)
+}
+
+// Close releases the job object
+func (j *ProcessJob) Close() error {
+	return windows.CloseHandle(j.handle)
+}
+
+// NativeArch returns the machine's native architecture as a GOARCH name. An
+// amd64 build of jv running under emulation on ARM64 Windows reports "arm64".
+func NativeArch() string {
+	var processMachine, nativeMachine uint16
+	if err := windows.IsWow64Process2(windows.CurrentProcess(), &processMachine, &nativeMachine); err != nil {
+		// Not available before Windows 10 1709, where emulation of a
+		// different architecture does not exist either
+		return runtime.GOARCH
+	}
+
+	switch nativeMachine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		return "arm"
+	}
+	return runtime.GOARCH
+}
+
+// IsFileLocked reports whether err was caused by a file that another process
+// holds open, such as a running java.exe inside the directory being removed.
+// Renaming a directory that holds a running program or a loaded DLL fails
+// with access denied, so that counts as locked too when the caller has write
+// access to the directory anyway.
+func IsFileLocked(err error, canWrite bool) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		(canWrite && errors.Is(err, windows.ERROR_ACCESS_DENIED))
+}
+
+// IsAccessDenied reports whether err was caused by missing permissions, e.g.
+// deleting a system-wide JDK without administrator rights. Check IsFileLocked
+// first when the caller may have write access.
+func IsAccessDenied(err error) bool {
+	return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
+
 // GetRefreshCommand returns a PowerShell command to refresh environment in the current session
 // This must be executed by the PowerShell session itself, not from within jv.exe
 func GetRefreshCommand() string {
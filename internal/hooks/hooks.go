@@ -0,0 +1,152 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"jv/internal/config"
+	"jv/internal/env"
+	"jv/internal/theme"
+)
+
+// Events hooks can be configured for in the "hooks" section of the config
+const (
+	PreInstall    = "pre-install"    // New JDK verified and extracted to a staging directory, about to be moved into place
+	PostInstall   = "post-install"   // New JDK in place
+	PreSwitch     = "pre-switch"     // JAVA_HOME about to change
+	PostSwitch    = "post-switch"    // JAVA_HOME changed
+	PostUninstall = "post-uninstall" // JDK deleted
+)
+
+// Events lists every hook event in the order they can occur
+var Events = []string{PreInstall, PostInstall, PreSwitch, PostSwitch, PostUninstall}
+
+// hookTimeout bounds a single hook command, e.g. an IDE registration script
+const hookTimeout = 10 * time.Minute
+
+// ErrHookFailed is returned when a hook command exits with an error. A failing
+// pre-hook aborts the operation; callers only warn about post-hooks.
+var ErrHookFailed = errors.New("hook failed")
+
+// Context describes the JDK an operation concerns. Hook commands receive it
+// as JV_* environment variables.
+type Context struct {
+	Path         string // JV_JDK_PATH; the staging directory for pre-install
+	TargetPath   string // JV_JDK_TARGET_PATH, where an install puts the JDK
+	Version      string // JV_JDK_VERSION, e.g. "21"
+	Release      string // JV_JDK_RELEASE, e.g. "21.0.5+11"
+	Vendor       string // JV_JDK_VENDOR, e.g. "Eclipse Adoptium"
+	Scope        string // JV_JDK_SCOPE, "system" or "user"
+	ImageType    string // JV_JDK_IMAGE_TYPE
+	Arch         string // JV_JDK_ARCH
+	PreviousPath string // JV_PREVIOUS_JAVA_HOME, set for switches
+}
+
+// environ returns the environment for a hook command: the current process
+// environment plus the context
+func (c Context) environ(event string) []string {
+	vars := os.Environ()
+	add := func(name string, value string) {
+		vars = append(vars, name+"="+value)
+	}
+
+	add("JV_HOOK", event)
+	add("JV_JDK_PATH", c.Path)
+	add("JV_JDK_TARGET_PATH", c.TargetPath)
+	add("JV_JDK_VERSION", c.Version)
+	add("JV_JDK_RELEASE", c.Release)
+	add("JV_JDK_VENDOR", c.Vendor)
+	add("JV_JDK_SCOPE", c.Scope)
+	add("JV_JDK_IMAGE_TYPE", c.ImageType)
+	add("JV_JDK_ARCH", c.Arch)
+	add("JV_PREVIOUS_JAVA_HOME", c.PreviousPath)
+	return vars
+}
+
+// Run runs the commands configured for event in order and stops at the first
+// one that fails. A config that cannot be read fails pre-hooks, since their
+// checks would otherwise be skipped silently, and only warns for post-hooks.
+// Cancelling ctx stops the running command and returns the cause.
+func Run(ctx context.Context, event string, hookCtx Context) error {
+	cfg, err := config.Load()
+	if err != nil {
+		if strings.HasPrefix(event, "pre-") {
+			return fmt.Errorf("cannot run %s hooks: %w", event, err)
+		}
+		fmt.Println(theme.WarningMessage(fmt.Sprintf("Skipped %s hooks: %v", event, err)))
+		return nil
+	}
+
+	for _, command := range cfg.Hooks[event] {
+		fmt.Println(theme.Faint.Render(fmt.Sprintf("Running %s hook: %s", event, command)))
+		if err := runCommand(ctx, command, hookCtx.environ(event)); err != nil {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			return fmt.Errorf("%w: %s hook %q: %v", ErrHookFailed, event, command, err)
+		}
+	}
+	return nil
+}
+
+// runCommand runs a hook command line through cmd.exe, so it may call
+// scripts, use pipes or start PowerShell, with the terminal attached. When
+// ctx is cancelled or after hookTimeout it is killed together with the
+// programs it started.
+func runCommand(ctx context.Context, command string, environ []string) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	shell := os.Getenv("ComSpec")
+	if shell == "" {
+		shell = "cmd.exe"
+	}
+
+	cmd := exec.Command(shell)
+	// Pass the command line verbatim; cmd.exe does not follow the quoting
+	// rules exec uses for arguments
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: syscall.EscapeArg(shell) + ` /d /s /c "` + command + `"`}
+	cmd.Env = environ
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Killing cmd.exe alone would leave a script or java.exe it started
+	// running, so the hook runs in a job object that is terminated as a
+	// whole. Without one, only cmd.exe can be killed.
+	job, err := env.StartInJob(cmd)
+	if err != nil {
+		return err
+	}
+	if job != nil {
+		defer job.Close()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if job != nil {
+			job.Terminate()
+		} else {
+			cmd.Process.Kill()
+		}
+		<-done
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", hookTimeout)
+	}
+	return ctx.Err()
+}
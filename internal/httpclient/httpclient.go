@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"jv/internal/config"
+)
+
+const (
+	// ConnectTimeout bounds dialing and the TLS handshake
+	ConnectTimeout = 30 * time.Second
+
+	// ResponseHeaderTimeout bounds the wait for response headers after a request is sent
+	ResponseHeaderTimeout = 60 * time.Second
+)
+
+// UserAgent is sent with every request; main sets it to include the jv version
+var UserAgent = "jv"
+
+var (
+	transportOnce sync.Once
+	transport     http.RoundTripper
+	transportErr  error
+)
+
+// Client returns an HTTP client that honours the proxy and CA bundle settings
+// in the jv config and sends the jv User-Agent. It fails if the config cannot
+// be read. A zero timeout means no overall limit, which suits large downloads
+// guarded by their own stall checks.
+func Client(timeout time.Duration) (*http.Client, error) {
+	transportOnce.Do(func() {
+		cfg, err := config.Load()
+		if err != nil {
+			// Connecting without the configured proxy or CA bundles would
+			// fail later with a confusing network or certificate error
+			transportErr = fmt.Errorf("failed to load network settings: %w", err)
+			return
+		}
+		transport, transportErr = NewTransport(cfg.Network)
+	})
+	if transportErr != nil {
+		return nil, transportErr
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// NewTransport builds a transport for the given network settings
+func NewTransport(network config.NetworkConfig) (http.RoundTripper, error) {
+	tlsConfig, err := tlsConfigFor(network.CABundles)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := proxyFunc(network)
+	if err != nil {
+		return nil, err
+	}
+
+	base := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   ConnectTimeout,
+		ResponseHeaderTimeout: ResponseHeaderTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &userAgentTransport{base: base}, nil
+}
+
+// userAgentTransport adds the jv User-Agent to requests that do not set one
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// tlsConfigFor returns a TLS config trusting the system roots plus the given PEM bundles
+func tlsConfigFor(bundles []string) (*tls.Config, error) {
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, bundle := range bundles {
+		data, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", bundle, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", bundle)
+		}
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// proxyFunc returns the proxy selector for the given settings. Explicit
+// settings in the config take precedence over HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY from the environment.
+func proxyFunc(network config.NetworkConfig) (func(*http.Request) (*url.URL, error), error) {
+	if network.HTTPProxy == "" && network.HTTPSProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	httpProxy, err := parseProxyURL(network.HTTPProxy)
+	if err != nil {
+		return nil, err
+	}
+	httpsProxy, err := parseProxyURL(network.HTTPSProxy)
+	if err != nil {
+		return nil, err
+	}
+	if httpsProxy == nil {
+		httpsProxy = httpProxy
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), network.NoProxy) {
+			return nil, nil
+		}
+		if req.URL.Scheme == "https" {
+			return httpsProxy, nil
+		}
+		return httpProxy, nil
+	}, nil
+}
+
+// parseProxyURL parses a proxy address, defaulting to the http scheme
+func parseProxyURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", raw)
+	}
+	return u, nil
+}
+
+// bypassProxy reports whether host matches the comma-separated no_proxy list.
+// Entries match the host itself and its subdomains; "*" matches everything.
+func bypassProxy(host string, noProxy string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, "*")
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
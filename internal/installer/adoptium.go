@@ -1,21 +1,49 @@
 package installer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
+	"time"
+
+	"jv/internal/cache"
+	"jv/internal/config"
+	"jv/internal/httpclient"
 )
 
-const adoptiumAPIBase = "https://api.adoptium.net/v3"
+const (
+	// AdoptiumID is the config key for the Adoptium distributor
+	AdoptiumID = "adoptium"
+
+	// DefaultAdoptiumAPIBase is used when no API base URLs are configured
+	DefaultAdoptiumAPIBase = "https://api.adoptium.net/v3"
+)
 
 // AdoptiumDistributor implements the Distributor interface for Eclipse Adoptium
-type AdoptiumDistributor struct{}
+type AdoptiumDistributor struct {
+	apiBases []string            // Ordered API endpoints, tried until one answers
+	mirrors  []config.MirrorRule // Download mirrors, also used for checksum files
+}
 
-// NewAdoptiumDistributor creates a new Adoptium distributor
-func NewAdoptiumDistributor() *AdoptiumDistributor {
-	return &AdoptiumDistributor{}
+// NewAdoptiumDistributor creates a new Adoptium distributor using the
+// configured API base URLs in order, or the public Adoptium API if none are
+// configured
+func NewAdoptiumDistributor(settings config.DistributorConfig) *AdoptiumDistributor {
+	apiBases := settings.APIBaseURLs
+	if len(apiBases) == 0 {
+		apiBases = []string{DefaultAdoptiumAPIBase}
+	}
+	return &AdoptiumDistributor{apiBases: apiBases, mirrors: settings.Mirrors}
+}
+
+// ID returns the distributor config key
+func (a *AdoptiumDistributor) ID() string {
+	return AdoptiumID
 }
 
 // Name returns the distributor name
@@ -29,46 +57,67 @@ type adoptiumReleasesResponse struct {
 	AvailableReleases        []int `json:"available_releases"`
 	MostRecentLTS            int   `json:"most_recent_lts"`
 	MostRecentFeatureRelease int   `json:"most_recent_feature_release"`
+	TipVersion               int   `json:"tip_version"` // Newest version in development, built as early access
+}
+
+// adoptiumPackage is a downloadable archive in an API response
+type adoptiumPackage struct {
+	Link          string `json:"link"`
+	Checksum      string `json:"checksum"`
+	ChecksumLink  string `json:"checksum_link"`
+	SignatureLink string `json:"signature_link"`
+	Size          int64  `json:"size"`
+	Name          string `json:"name"`
 }
 
 // adoptiumAssetResponse represents the API response for asset details
 type adoptiumAssetResponse struct {
 	Binary struct {
-		Package struct {
-			Link     string `json:"link"`
-			Checksum string `json:"checksum"`
-			Size     int64  `json:"size"`
-			Name     string `json:"name"`
-		} `json:"package"`
+		Package   adoptiumPackage `json:"package"`
+		UpdatedAt time.Time       `json:"updated_at"`
 	} `json:"binary"`
-	Version struct {
+	ReleaseName string `json:"release_name"`
+	Version     struct {
 		OpenJDKVersion string `json:"openjdk_version"`
 		Major          int    `json:"major"`
 	} `json:"version"`
 }
 
-// GetAvailableVersions fetches available Java versions from Adoptium API
-func (a *AdoptiumDistributor) GetAvailableVersions() ([]JavaRelease, error) {
-	url := fmt.Sprintf("%s/info/available_releases", adoptiumAPIBase)
+// adoptiumFeatureRelease represents one release in the feature_releases
+// response, which unlike the latest assets also covers early-access builds
+type adoptiumFeatureRelease struct {
+	Binaries []struct {
+		Package adoptiumPackage `json:"package"`
+	} `json:"binaries"`
+	ReleaseName string    `json:"release_name"`
+	Timestamp   time.Time `json:"timestamp"`
+}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return a.getFallbackVersions(), fmt.Errorf("API request failed, using fallback versions: %w", err)
-	}
-	defer resp.Body.Close()
+// adoptiumReleaseNotesResponse represents the API response for release notes
+type adoptiumReleaseNotesResponse struct {
+	ReleaseNotes []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Type  string `json:"type"`
+		Link  string `json:"link"`
+	} `json:"release_notes"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return a.getFallbackVersions(), fmt.Errorf("API returned status %d, using fallback versions", resp.StatusCode)
-	}
+// adoptiumReleaseNotesPage is the human-readable release notes page
+const adoptiumReleaseNotesPage = "https://adoptium.net/temurin/release-notes/?version="
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return a.getFallbackVersions(), fmt.Errorf("failed to read response, using fallback versions: %w", err)
-	}
+// GetAvailableVersions fetches available Java versions from Adoptium API
+func (a *AdoptiumDistributor) GetAvailableVersions(ctx context.Context) ([]JavaRelease, error) {
+	releases, _, err := a.fetchVersions(ctx, "")
+	return releases, err
+}
 
-	var releasesResp adoptiumReleasesResponse
-	if err := json.Unmarshal(body, &releasesResp); err != nil {
-		return a.getFallbackVersions(), fmt.Errorf("failed to parse response, using fallback versions: %w", err)
+// fetchVersions lists the GA versions, asking the API only for changes since
+// the listing with the given ETag; it returns nil releases if there are none
+func (a *AdoptiumDistributor) fetchVersions(ctx context.Context, etag string) ([]JavaRelease, cache.MetadataInfo, error) {
+	releasesResp, validators, err := a.fetchAvailableReleases(ctx, etag)
+	if err != nil || releasesResp == nil {
+		return nil, validators, err
 	}
 
 	// Convert to JavaRelease structs
@@ -90,39 +139,83 @@ func (a *AdoptiumDistributor) GetAvailableVersions() ([]JavaRelease, error) {
 		return releases[i].Version > releases[j].Version
 	})
 
+	return releases, validators, nil
+}
+
+// GetEarlyAccessVersions lists the versions newer than the latest GA release
+// that Adoptium publishes early-access builds of
+func (a *AdoptiumDistributor) GetEarlyAccessVersions(ctx context.Context) ([]JavaRelease, error) {
+	releasesResp, _, err := a.fetchAvailableReleases(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []JavaRelease
+	for v := releasesResp.TipVersion; v > releasesResp.MostRecentFeatureRelease; v-- {
+		releases = append(releases, JavaRelease{Version: fmt.Sprintf("%d", v), IsEA: true})
+	}
 	return releases, nil
 }
 
-// getFallbackVersions returns a hardcoded list of versions as fallback
-func (a *AdoptiumDistributor) getFallbackVersions() []JavaRelease {
-	return []JavaRelease{
-		{Version: "25", IsLTS: true},
-		{Version: "24", IsLTS: false},
-		{Version: "23", IsLTS: false},
-		{Version: "22", IsLTS: false},
-		{Version: "21", IsLTS: true},
-		{Version: "20", IsLTS: false},
-		{Version: "19", IsLTS: false},
-		{Version: "18", IsLTS: false},
-		{Version: "17", IsLTS: true},
-		{Version: "16", IsLTS: false},
-		{Version: "11", IsLTS: true},
-		{Version: "8", IsLTS: true},
-	}
-}
-
-// GetDownloadURL fetches download information for a specific version and architecture
-func (a *AdoptiumDistributor) GetDownloadURL(version string, arch string) (*DownloadInfo, error) {
-	// Map Go arch to Adoptium arch
-	adoptiumArch := arch
-	if arch == "amd64" {
-		adoptiumArch = "x64"
+// fetchAvailableReleases queries the feature releases Adoptium knows about.
+// With an ETag it returns a nil response if they have not changed.
+func (a *AdoptiumDistributor) fetchAvailableReleases(ctx context.Context, etag string) (*adoptiumReleasesResponse, cache.MetadataInfo, error) {
+	header := make(http.Header)
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	resp, err := a.request(ctx, "/info/available_releases", header)
+	if err != nil {
+		return nil, cache.MetadataInfo{}, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	validators := cacheValidators(resp)
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		if validators.ETag == "" {
+			validators.ETag = etag
+		}
+		return nil, validators, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, cache.MetadataInfo{}, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, cache.MetadataInfo{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var releasesResp adoptiumReleasesResponse
+	if err := json.Unmarshal(body, &releasesResp); err != nil {
+		return nil, cache.MetadataInfo{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &releasesResp, validators, nil
+}
+
+// adoptiumArchitectures maps jv architectures to the names the Adoptium API uses
+var adoptiumArchitectures = map[string]string{
+	ArchX86:     "x86",
+	ArchX64:     "x64",
+	ArchAArch64: "aarch64",
+	ArchARM:     "arm",
+}
+
+// GetDownloadURL fetches download information for a specific version, architecture and image type
+func (a *AdoptiumDistributor) GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	adoptiumArch, ok := adoptiumArchitectures[arch]
+	if !ok {
+		return nil, fmt.Errorf("%s does not offer builds for %s", a.Name(), ArchLabel(arch))
+	}
+
+	if imageType == "" {
+		imageType = ImageTypeJDK
 	}
 
-	url := fmt.Sprintf("%s/assets/latest/%s/hotspot?architecture=%s&image_type=jdk&os=windows&vendor=eclipse",
-		adoptiumAPIBase, version, adoptiumArch)
+	path := fmt.Sprintf("/assets/latest/%s/hotspot?architecture=%s&image_type=%s&os=windows&vendor=eclipse",
+		version, adoptiumArch, imageType)
 
-	resp, err := http.Get(url)
+	resp, err := a.get(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query download URL: %w", err)
 	}
@@ -143,15 +236,230 @@ func (a *AdoptiumDistributor) GetDownloadURL(version string, arch string) (*Down
 	}
 
 	if len(assets) == 0 {
-		return nil, fmt.Errorf("no JDK found for Java %s on %s", version, arch)
+		return nil, fmt.Errorf("no %s found for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+	}
+
+	return a.downloadInfo(ctx, assets[0].Binary.Package, assets[0].ReleaseName, arch, imageType)
+}
+
+// GetEarlyAccessDownloadURL fetches download information for the newest
+// early-access build of a version
+func (a *AdoptiumDistributor) GetEarlyAccessDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	adoptiumArch, ok := adoptiumArchitectures[arch]
+	if !ok {
+		return nil, fmt.Errorf("%s does not offer builds for %s", a.Name(), ArchLabel(arch))
+	}
+
+	if imageType == "" {
+		imageType = ImageTypeJDK
+	}
+
+	path := fmt.Sprintf("/assets/feature_releases/%s/ea?architecture=%s&image_type=%s&os=windows&vendor=eclipse&jvm_impl=hotspot&page=0&page_size=1&sort_method=DATE&sort_order=DESC",
+		version, adoptiumArch, imageType)
+
+	resp, err := a.get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query download URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The API answers 404 when no build matches
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("no early-access %s found for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d for version %s", resp.StatusCode, version)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var releases []adoptiumFeatureRelease
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(releases) == 0 || len(releases[0].Binaries) == 0 {
+		return nil, fmt.Errorf("no early-access %s found for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+	}
+
+	info, err := a.downloadInfo(ctx, releases[0].Binaries[0].Package, releases[0].ReleaseName, arch, imageType)
+	if err != nil {
+		return nil, err
+	}
+	info.EarlyAccess = true
+	return info, nil
+}
+
+// downloadInfo builds download information for a package of a release,
+// falling back to the detached checksum file when the API omits the digest
+func (a *AdoptiumDistributor) downloadInfo(ctx context.Context, pkg adoptiumPackage, releaseName string, arch string, imageType string) (*DownloadInfo, error) {
+	checksum := pkg.Checksum
+	if checksum == "" && pkg.ChecksumLink != "" {
+		var err error
+		checksum, err = fetchChecksumFile(ctx, pkg.ChecksumLink, pkg.Name, a.mirrors)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	asset := assets[0]
 	return &DownloadInfo{
-		URL:          asset.Binary.Package.Link,
-		Checksum:     asset.Binary.Package.Checksum,
-		ChecksumAlgo: "SHA256",
-		Size:         asset.Binary.Package.Size,
-		FileName:     asset.Binary.Package.Name,
+		URL:          pkg.Link,
+		Checksum:     checksum,
+		ChecksumAlgo: ChecksumSHA256,
+		Size:         pkg.Size,
+		FileName:     pkg.Name,
+		ImageType:    imageType,
+		Arch:         arch,
+		Release:      strings.TrimPrefix(strings.TrimPrefix(releaseName, "jdk"), "-"),
+		SignatureURL: pkg.SignatureLink,
 	}, nil
 }
+
+// GetReleaseNotes fetches the date, notes and fixed CVEs of a release, or of
+// the latest GA release of a major version
+func (a *AdoptiumDistributor) GetReleaseNotes(ctx context.Context, version string) (*ReleaseNotes, error) {
+	releaseName, date, err := a.releaseInfo(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := &ReleaseNotes{
+		Release: strings.TrimPrefix(strings.TrimPrefix(releaseName, "jdk"), "-"),
+		Date:    date,
+		URL:     adoptiumReleaseNotesPage + url.QueryEscape(releaseName),
+	}
+
+	resp, err := a.get(ctx, "/assets/release_notes/"+url.PathEscape(releaseName)+"?vendor=eclipse")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release notes: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Notes are published some time after the binaries
+	if resp.StatusCode == http.StatusNotFound {
+		notes.Unpublished = true
+		return notes, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d for the notes of %s", resp.StatusCode, releaseName)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var notesResp adoptiumReleaseNotesResponse
+	if err := json.Unmarshal(body, &notesResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var titles []string
+	for _, n := range notesResp.ReleaseNotes {
+		notes.Changes = append(notes.Changes, ReleaseChange{ID: n.ID, Title: n.Title, Type: n.Type, Link: n.Link})
+		titles = append(titles, n.Title)
+	}
+	notes.CVEs = collectCVEs(titles...)
+	return notes, nil
+}
+
+// releaseInfo returns the Adoptium release name, e.g. "jdk-21.0.5+11", and
+// publication date of a full release or of the latest release of a major version
+func (a *AdoptiumDistributor) releaseInfo(ctx context.Context, version string) (string, time.Time, error) {
+	adoptiumArch := adoptiumArchitectures[HostArch()]
+	if adoptiumArch == "" {
+		adoptiumArch = "x64"
+	}
+	query := fmt.Sprintf("architecture=%s&image_type=jdk&os=windows&vendor=eclipse", adoptiumArch)
+
+	if MajorVersion(version) == version {
+		resp, err := a.get(ctx, fmt.Sprintf("/assets/latest/%s/hotspot?%s", version, query))
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to query release: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", time.Time{}, fmt.Errorf("API returned status %d for version %s", resp.StatusCode, version)
+		}
+
+		var assets []adoptiumAssetResponse
+		if err := json.NewDecoder(resp.Body).Decode(&assets); err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if len(assets) == 0 {
+			return "", time.Time{}, fmt.Errorf("no release found for Java %s", version)
+		}
+		return assets[0].ReleaseName, assets[0].Binary.UpdatedAt, nil
+	}
+
+	releaseName := adoptiumReleaseName(version)
+	resp, err := a.get(ctx, fmt.Sprintf("/assets/release_name/eclipse/%s?%s", url.PathEscape(releaseName), query))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to query release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", time.Time{}, fmt.Errorf("%s has no release %s", a.Name(), version)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("API returned status %d for release %s", resp.StatusCode, version)
+	}
+
+	var release adoptiumFeatureRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return releaseName, release.Timestamp, nil
+}
+
+// adoptiumReleaseName turns a release version into an Adoptium release name:
+// "jdk-21.0.5+11", or "jdk8u432-b06" for Java 8
+func adoptiumReleaseName(release string) string {
+	release = strings.TrimPrefix(strings.TrimPrefix(release, "jdk"), "-")
+	if legacyReleasePattern.MatchString(release) && !strings.HasPrefix(release, "1.") {
+		return "jdk" + release
+	}
+	return "jdk-" + release
+}
+
+// get sends a GET request to the first API base URL that answers.
+// Connection errors and 5xx responses fail over to the next base URL.
+func (a *AdoptiumDistributor) get(ctx context.Context, path string) (*http.Response, error) {
+	return a.request(ctx, path, nil)
+}
+
+// request is get with extra request headers
+func (a *AdoptiumDistributor) request(ctx context.Context, path string, header http.Header) (*http.Response, error) {
+	client, err := httpclient.Client(apiTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, base := range a.apiBases {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s returned status %d", base, resp.StatusCode)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
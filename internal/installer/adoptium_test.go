@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jv/internal/config"
+)
+
+// newTestAdoptium returns an Adoptium distributor whose API is served by
+// handler
+func newTestAdoptium(t *testing.T, handler http.HandlerFunc) *AdoptiumDistributor {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewAdoptiumDistributor(config.DistributorConfig{APIBaseURLs: []string{server.URL}})
+}
+
+func TestAdoptiumEarlyAccessVersions(t *testing.T) {
+	adoptium := newTestAdoptium(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"available_lts_releases":[17,21],"available_releases":[17,21,23],"most_recent_feature_release":23,"tip_version":25}`))
+	})
+
+	releases, err := adoptium.GetEarlyAccessVersions(context.Background())
+	if err != nil {
+		t.Fatalf("GetEarlyAccessVersions(): %v", err)
+	}
+	if got := releaseList(releases); got != "25 EA , 24 EA " {
+		t.Errorf("GetEarlyAccessVersions() = %q, want 25 and 24 as early access", got)
+	}
+}
+
+func TestAdoptiumEarlyAccessDownloadURL(t *testing.T) {
+	var query string
+	adoptium := newTestAdoptium(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/assets/feature_releases/24/ea" {
+			query = r.URL.RawQuery
+			w.Write([]byte(`[{"release_name":"jdk-24+20-ea-beta","binaries":[{"package":{
+				"link":"https://github.com/adoptium/temurin24-binaries/releases/download/jdk-24%2B20-ea-beta/jdk-24-ea.zip",
+				"checksum":"` + sha256Digest + `","name":"jdk-24-ea.zip","size":1024}}]}]`))
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	info, err := adoptium.GetEarlyAccessDownloadURL(context.Background(), "24", ArchX64, "")
+	if err != nil {
+		t.Fatalf("GetEarlyAccessDownloadURL(): %v", err)
+	}
+	if !info.EarlyAccess || info.Release != "24+20-ea-beta" || info.FileName != "jdk-24-ea.zip" {
+		t.Errorf("GetEarlyAccessDownloadURL() = %+v, want the early-access build 24+20-ea-beta", info)
+	}
+	for _, param := range []string{"architecture=x64", "image_type=jdk", "sort_order=DESC"} {
+		if !strings.Contains(query, param) {
+			t.Errorf("query %q lacks %s", query, param)
+		}
+	}
+
+	_, err = adoptium.GetEarlyAccessDownloadURL(context.Background(), "23", ArchX64, ImageTypeJRE)
+	if err == nil || !strings.Contains(err.Error(), "no early-access JRE found for Java 23") {
+		t.Errorf("GetEarlyAccessDownloadURL() of a version without builds error = %v, want none found", err)
+	}
+}
@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	"jv/internal/env"
+)
+
+// Architectures jv can install for, named like GOARCH. Distributors map them
+// to their own names in GetDownloadURL.
+const (
+	ArchX86     = "386"
+	ArchX64     = "amd64"
+	ArchAArch64 = "arm64"
+	ArchARM     = "arm"
+)
+
+// archAliases maps the names users and release files use to an architecture
+var archAliases = map[string]string{
+	"x86":     ArchX86,
+	"x32":     ArchX86,
+	"i386":    ArchX86,
+	"i586":    ArchX86,
+	"i686":    ArchX86,
+	"386":     ArchX86,
+	"x64":     ArchX64,
+	"x86_64":  ArchX64,
+	"x86-64":  ArchX64,
+	"amd64":   ArchX64,
+	"aarch64": ArchAArch64,
+	"arm64":   ArchAArch64,
+	"arm":     ArchARM,
+	"arm32":   ArchARM,
+	"aarch32": ArchARM,
+}
+
+// ParseArch turns an architecture name such as "x86", "x64", "aarch64" or
+// "arm" into the name jv uses internally
+func ParseArch(name string) (string, error) {
+	if arch, ok := archAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return arch, nil
+	}
+	return "", fmt.Errorf("unknown architecture %q (use x86, x64, aarch64 or arm)", name)
+}
+
+// ArchLabel returns the name users know an architecture by, e.g. "x64"
+func ArchLabel(arch string) string {
+	switch arch {
+	case ArchX86:
+		return "x86"
+	case ArchX64:
+		return "x64"
+	case ArchAArch64:
+		return "aarch64"
+	case ArchARM:
+		return "arm"
+	}
+	return arch
+}
+
+// HostArch returns the native architecture of this machine
+func HostArch() string {
+	return env.NativeArch()
+}
+
+// CanRunArch reports whether binaries built for arch run on this machine,
+// natively or under the emulation Windows provides: x86 everywhere, and x64
+// on ARM64
+func CanRunArch(arch string) bool {
+	host := HostArch()
+	switch {
+	case arch == "" || arch == host:
+		return true
+	case arch == ArchX86:
+		return host == ArchX64 || host == ArchAArch64
+	case arch == ArchX64:
+		return host == ArchAArch64
+	}
+	return false
+}
+
+// JDKArch returns the architecture recorded in a JDK's release file, or ""
+func JDKArch(jdkPath string) string {
+	values, err := ReadReleaseFile(jdkPath)
+	if err != nil {
+		return ""
+	}
+	arch, err := ParseArch(values["OS_ARCH"])
+	if err != nil {
+		return ""
+	}
+	return arch
+}
@@ -0,0 +1,50 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseArch(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string // Substring of the expected error; empty for success
+	}{
+		{name: "x86", want: ArchX86},
+		{name: "i686", want: ArchX86},
+		{name: "386", want: ArchX86},
+		{name: "x64", want: ArchX64},
+		{name: "x86_64", want: ArchX64},
+		{name: "x86-64", want: ArchX64},
+		{name: "AMD64", want: ArchX64},
+		{name: " x64 ", want: ArchX64},
+		{name: "aarch64", want: ArchAArch64},
+		{name: "arm64", want: ArchAArch64},
+		{name: "arm", want: ArchARM},
+		{name: "aarch32", want: ArchARM},
+		{name: "", wantErr: "unknown architecture"},
+		{name: "sparcv9", wantErr: `unknown architecture "sparcv9"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArch(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseArch(%q) error = %v, want error containing %q", tt.name, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArch(%q): %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseArch(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if back, err := ParseArch(ArchLabel(got)); err != nil || back != got {
+				t.Errorf("ParseArch(ArchLabel(%q)) = %q, %v, want %q", got, back, err, got)
+			}
+		})
+	}
+}
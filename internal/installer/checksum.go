@@ -0,0 +1,256 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"jv/internal/config"
+)
+
+// Checksum algorithms accepted in DownloadInfo.ChecksumAlgo
+const (
+	ChecksumSHA1   = "SHA1"
+	ChecksumSHA256 = "SHA256"
+	ChecksumSHA512 = "SHA512"
+)
+
+var (
+	// ErrChecksumMismatch means a file does not have the expected digest
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrNoChecksum means the distributor published no checksum for an archive,
+	// so it cannot be verified
+	ErrNoChecksum = errors.New("no checksum available")
+)
+
+// detachedChecksumSuffixes are tried, in order, next to an archive URL
+var detachedChecksumSuffixes = []string{".sha512", ".sha256.txt", ".sha256", ".sha1"}
+
+// bsdChecksumLine matches the "SHA256 (file) = digest" format of shasum --tag
+var bsdChecksumLine = regexp.MustCompile(`^(SHA\d+)\s*\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+
+// newChecksumHash returns the hash for an algorithm name such as "SHA256",
+// "sha-512" or "SHA1". An empty name means SHA-256.
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch normalizeChecksumAlgo(algo) {
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+}
+
+// normalizeChecksumAlgo turns "sha-256" and similar spellings into ChecksumSHA256
+func normalizeChecksumAlgo(algo string) string {
+	algo = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(algo), "-", ""))
+	if algo == "" {
+		return ChecksumSHA256
+	}
+	return algo
+}
+
+// checksumAlgoForDigest guesses the algorithm from the length of a hex digest
+func checksumAlgoForDigest(digest string) string {
+	switch len(digest) {
+	case 40:
+		return ChecksumSHA1
+	case 128:
+		return ChecksumSHA512
+	}
+	return ChecksumSHA256
+}
+
+// ChecksumLabel returns the display name of an algorithm, e.g. "SHA-256"
+func ChecksumLabel(algo string) string {
+	algo = normalizeChecksumAlgo(algo)
+	return strings.Replace(algo, "SHA", "SHA-", 1)
+}
+
+// VerifyChecksum verifies the checksum of a file with the given algorithm
+func VerifyChecksum(filePath string, expectedChecksum string, algo string) error {
+	actualChecksum, err := FileDigest(filePath, algo)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(actualChecksum, expectedChecksum) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expectedChecksum, actualChecksum)
+	}
+
+	return nil
+}
+
+// FileChecksum returns the hex-encoded SHA256 checksum of a file
+func FileChecksum(filePath string) (string, error) {
+	return FileDigest(filePath, ChecksumSHA256)
+}
+
+// FileDigest returns the hex-encoded checksum of a file with the given algorithm
+func FileDigest(filePath string, algo string) (string, error) {
+	hasher, err := newChecksumHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// streamChecksum hashes a download as it is written, so the archive does not
+// have to be read again to verify it
+type streamChecksum struct {
+	hash     hash.Hash
+	expected string
+}
+
+// newStreamChecksum returns nil when there is no checksum to verify
+func newStreamChecksum(expected string, algo string) (*streamChecksum, error) {
+	if expected == "" {
+		return nil, nil
+	}
+	h, err := newChecksumHash(algo)
+	if err != nil {
+		return nil, err
+	}
+	return &streamChecksum{hash: h, expected: expected}, nil
+}
+
+// resume restarts the hash for a download continuing at offset, feeding it the
+// bytes already in partPath
+func (c *streamChecksum) resume(partPath string, offset int64) error {
+	c.hash.Reset()
+	if offset == 0 {
+		return nil
+	}
+
+	f, err := os.Open(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to read partial download: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.CopyN(c.hash, f, offset); err != nil {
+		return fmt.Errorf("failed to read partial download: %w", err)
+	}
+	return nil
+}
+
+// verify compares the digest of everything written with the expected one
+func (c *streamChecksum) verify() error {
+	actual := hex.EncodeToString(c.hash.Sum(nil))
+	if !strings.EqualFold(actual, c.expected) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, c.expected, actual)
+	}
+	return nil
+}
+
+// fetchChecksumFile downloads a detached checksum file, through the mirrors
+// rules give, and returns the digest it lists for fileName
+func fetchChecksumFile(ctx context.Context, url string, fileName string, rules []config.MirrorRule) (string, error) {
+	data, err := fetchSmallMirrored(ctx, url, rules)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksum file: %w", err)
+	}
+	return parseChecksumFile(string(data), fileName)
+}
+
+// parseChecksumFile finds the digest for fileName in the output of sha256sum
+// and similar tools. A file holding a single digest, with or without a name,
+// applies to any file.
+func parseChecksumFile(data string, fileName string) (string, error) {
+	var lines [][2]string // digest, name
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			lines = append(lines, [2]string{m[3], m[2]})
+			continue
+		}
+		fields := strings.Fields(line)
+		name := ""
+		if len(fields) > 1 {
+			name = strings.TrimPrefix(fields[1], "*")
+		}
+		lines = append(lines, [2]string{fields[0], name})
+	}
+
+	for _, l := range lines {
+		if l[1] != "" && path.Base(l[1]) == fileName && isHexDigest(l[0]) {
+			return strings.ToLower(l[0]), nil
+		}
+	}
+	if len(lines) == 1 && isHexDigest(lines[0][0]) {
+		return strings.ToLower(lines[0][0]), nil
+	}
+	return "", fmt.Errorf("checksum file lists no digest for %s", fileName)
+}
+
+// detachedChecksum looks for a checksum file next to an archive URL or path
+// and returns the digest and its algorithm. Mirror rules apply to the
+// checksum file like to the archive.
+func detachedChecksum(ctx context.Context, archiveURL string, fileName string, rules []config.MirrorRule) (string, string, error) {
+	var lastErr error
+	for _, suffix := range detachedChecksumSuffixes {
+		digest, err := fetchChecksumFile(ctx, archiveURL+suffix, fileName, rules)
+		if err == nil {
+			return digest, checksumAlgoForDigest(digest), nil
+		}
+		lastErr = err
+	}
+	return "", "", fmt.Errorf("%w: no checksum file found next to %s (%v)", ErrNoChecksum, archiveURL, lastErr)
+}
+
+func isHexDigest(s string) bool {
+	if len(s) != 40 && len(s) != 64 && len(s) != 128 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// digestLengths holds the length of the hex digest of each algorithm
+var digestLengths = map[string]int{
+	ChecksumSHA1:   40,
+	ChecksumSHA256: 64,
+	ChecksumSHA512: 128,
+}
+
+// validateDigest checks that digest is a hex digest of the given algorithm
+func validateDigest(digest string, algo string) error {
+	want, ok := digestLengths[normalizeChecksumAlgo(algo)]
+	if !ok {
+		return fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return fmt.Errorf("checksum %q is not hexadecimal", digest)
+	}
+	if len(digest) != want {
+		return fmt.Errorf("%s checksum has %d hex digits, expected %d", ChecksumLabel(algo), len(digest), want)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksumFile(t *testing.T) {
+	const (
+		sha256a = "b2c1a6f0d6e1e3f8a0e6c9d1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5"
+		sha256b = "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+		sha1    = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	)
+	tests := []struct {
+		name     string
+		data     string
+		fileName string
+		want     string
+		wantErr  string // Substring of the expected error; empty for success
+	}{
+		{
+			name:     "bare digest",
+			data:     sha256a + "\n",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "sha256sum line",
+			data:     sha256a + "  jdk.zip\n",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "binary mode marker",
+			data:     sha256a + " *jdk.zip",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "several files",
+			data:     sha256a + "  jdk.zip\n" + sha256b + "  jdk.tar.gz\n",
+			fileName: "jdk.tar.gz",
+			want:     sha256b,
+		},
+		{
+			name:     "name with directory",
+			data:     sha256b + "  dist/21/jdk.zip\n",
+			fileName: "jdk.zip",
+			want:     sha256b,
+		},
+		{
+			name:     "BSD format",
+			data:     "SHA256 (jdk.zip) = " + sha256a,
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "comments and blank lines",
+			data:     "# checksums\n\n" + sha256a + "  jdk.zip\r\n",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "upper case digest",
+			data:     strings.ToUpper(sha256a) + "  jdk.zip",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "SHA-1 digest",
+			data:     sha1 + "  jdk.zip",
+			fileName: "jdk.zip",
+			want:     sha1,
+		},
+		{
+			name:     "single line for another file",
+			data:     sha256a + "  other.zip",
+			fileName: "jdk.zip",
+			want:     sha256a,
+		},
+		{
+			name:     "file not listed",
+			data:     sha256a + "  jdk.zip\n" + sha256b + "  jdk.tar.gz\n",
+			fileName: "jre.zip",
+			wantErr:  "lists no digest for jre.zip",
+		},
+		{
+			name:     "not a digest",
+			data:     "<html>Not Found</html>",
+			fileName: "jdk.zip",
+			wantErr:  "lists no digest",
+		},
+		{
+			name:     "digest of wrong length",
+			data:     "abc123  jdk.zip",
+			fileName: "jdk.zip",
+			wantErr:  "lists no digest",
+		},
+		{
+			name:     "empty file",
+			data:     "",
+			fileName: "jdk.zip",
+			wantErr:  "lists no digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksumFile(tt.data, tt.fileName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseChecksumFile() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseChecksumFile(): %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksumFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDigest(t *testing.T) {
+	sha256 := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		digest  string
+		algo    string
+		wantErr string // Substring of the expected error; empty for success
+	}{
+		{name: "SHA-256", digest: sha256, algo: "SHA256"},
+		{name: "default algorithm", digest: sha256, algo: ""},
+		{name: "dashed lower case name", digest: sha256, algo: "sha-256"},
+		{name: "SHA-1", digest: strings.Repeat("0", 40), algo: "sha1"},
+		{name: "SHA-512", digest: strings.Repeat("f", 128), algo: "SHA512"},
+		{name: "upper case hex", digest: strings.ToUpper(sha256), algo: "SHA256"},
+		{name: "unknown algorithm", digest: sha256, algo: "MD5", wantErr: `unsupported checksum algorithm "MD5"`},
+		{name: "not hex", digest: strings.Repeat("zz", 32), algo: "SHA256", wantErr: "is not hexadecimal"},
+		{name: "too short", digest: sha256[:62], algo: "SHA256", wantErr: "SHA-256 checksum has 62 hex digits, expected 64"},
+		{name: "length of another algorithm", digest: sha256, algo: "SHA512", wantErr: "SHA-512 checksum has 64 hex digits, expected 128"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDigest(tt.digest, tt.algo)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateDigest(%q, %q): %v", tt.digest, tt.algo, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateDigest(%q, %q) error = %v, want error containing %q", tt.digest, tt.algo, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamChecksumResume(t *testing.T) {
+	content := []byte(strings.Repeat("jdk archive bytes ", 1000))
+	digest := sha256.Sum256(content)
+	expected := hex.EncodeToString(digest[:])
+
+	// An earlier run left the first part of the download behind
+	partPath := filepath.Join(t.TempDir(), "jdk.zip.part")
+	offset := int64(len(content) / 3)
+	if err := os.WriteFile(partPath, content[:offset], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := newStreamChecksum(strings.ToUpper(expected), "SHA256")
+	if err != nil {
+		t.Fatalf("newStreamChecksum(): %v", err)
+	}
+
+	// A first attempt hashed some bytes before failing; resuming starts over
+	sum.hash.Write([]byte("bytes of a failed attempt"))
+	if err := sum.resume(partPath, offset); err != nil {
+		t.Fatalf("resume(): %v", err)
+	}
+	sum.hash.Write(content[offset:])
+	if err := sum.verify(); err != nil {
+		t.Errorf("verify() after resuming at %d: %v", offset, err)
+	}
+
+	// A server that ignores the Range request sends everything again
+	if err := sum.resume(partPath, 0); err != nil {
+		t.Fatalf("resume() at 0: %v", err)
+	}
+	sum.hash.Write(content)
+	if err := sum.verify(); err != nil {
+		t.Errorf("verify() after restarting: %v", err)
+	}
+
+	// Hashing the part file again as well as the full body gives another digest
+	if err := sum.resume(partPath, offset); err != nil {
+		t.Fatalf("resume(): %v", err)
+	}
+	sum.hash.Write(content)
+	if err := sum.verify(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("verify() of the wrong bytes error = %v, want ErrChecksumMismatch", err)
+	}
+
+	if err := sum.resume(partPath, int64(len(content))); err == nil {
+		t.Error("resume() beyond the end of the part file succeeded, want an error")
+	}
+}
+
+func TestNewStreamChecksum(t *testing.T) {
+	sum, err := newStreamChecksum("", "SHA256")
+	if err != nil || sum != nil {
+		t.Errorf("newStreamChecksum() without a checksum = %v, %v, want nil, nil", sum, err)
+	}
+
+	if _, err := newStreamChecksum(strings.Repeat("ab", 32), "MD5"); err == nil {
+		t.Error("newStreamChecksum() with MD5 succeeded, want an error")
+	}
+
+	sum, err = newStreamChecksum(strings.Repeat("ab", 64), "sha-512")
+	if err != nil {
+		t.Fatalf("newStreamChecksum() with SHA-512: %v", err)
+	}
+	if size := sum.hash.Size(); size != 64 {
+		t.Errorf("SHA-512 hash size = %d, want 64", size)
+	}
+}
@@ -1,17 +1,48 @@
 package installer
 
-// Distributor represents a Java distribution provider
+import (
+	"context"
+
+	"jv/internal/config"
+)
+
+// Image types a distributor can be asked for
+const (
+	ImageTypeJDK        = "jdk"        // Full development kit
+	ImageTypeJRE        = "jre"        // Runtime only
+	ImageTypeDebugImage = "debugimage" // Debug symbols for the JDK
+	ImageTypeTestImage  = "testimage"  // JTReg test image
+)
+
+// ImageTypeFXSDK marks OpenJFX SDK archives, which jv fx installs next to
+// the JDKs
+const ImageTypeFXSDK = "javafx-sdk"
+
+// Distributor represents a Java distribution provider. Requests stop when
+// ctx is cancelled.
 type Distributor interface {
+	ID() string // Stable key used in the config file, e.g. "adoptium"
 	Name() string
-	GetAvailableVersions() ([]JavaRelease, error)
-	GetDownloadURL(version string, arch string) (*DownloadInfo, error)
+	GetAvailableVersions(ctx context.Context) ([]JavaRelease, error)
+	GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error)
 }
 
-// JavaRelease represents an available Java version
+// EarlyAccessDistributor is implemented by distributors that also publish
+// early-access builds of upcoming releases. They are listed and resolved
+// separately so GA and early-access builds are never mixed up.
+type EarlyAccessDistributor interface {
+	GetEarlyAccessVersions(ctx context.Context) ([]JavaRelease, error)
+	GetEarlyAccessDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error)
+}
+
+// JavaRelease represents an available Java version. Version listings are
+// cached as JSON; see listVersions.
 type JavaRelease struct {
-	Version        string
-	IsLTS          bool
-	OpenJDKVersion string
+	Version        string        `json:"version"`
+	IsLTS          bool          `json:"lts,omitempty"`
+	IsEA           bool          `json:"ea,omitempty"` // Early-access build of an unreleased version
+	OpenJDKVersion string        `json:"openjdk_version,omitempty"`
+	Notes          *ReleaseNotes `json:"notes,omitempty"` // Date, notes and CVEs of the latest release if the listing includes them; see FetchReleaseNotes
 }
 
 // DownloadInfo contains information needed to download a JDK
@@ -21,4 +52,66 @@ type DownloadInfo struct {
 	ChecksumAlgo string
 	Size         int64
 	FileName     string
+	ImageType    string
+	Arch         string
+	Release      string   // Full release version, e.g. "21.0.5+11"; empty if unknown
+	Mirrors      []string // Alternative URLs for the same file, tried in order before URL
+	SignatureURL string   // Detached OpenPGP signature of the file; empty if none is published
+	EarlyAccess  bool     // Early-access build rather than a GA release
+
+	verified    bool                // Checksum already checked while downloading
+	mirrorRules []config.MirrorRule // Rules Mirrors came from, applied to the signature and checksum files too
+}
+
+// DownloadURLs returns the mirrors followed by the original URL, without duplicates
+func (d *DownloadInfo) DownloadURLs() []string {
+	urls := make([]string, 0, len(d.Mirrors)+1)
+	seen := make(map[string]bool)
+	for _, u := range append(append([]string{}, d.Mirrors...), d.URL) {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// ImageTypeLabel returns a human-readable name for an image type
+func ImageTypeLabel(imageType string) string {
+	switch imageType {
+	case ImageTypeJRE:
+		return "JRE"
+	case ImageTypeDebugImage:
+		return "Debug image"
+	case ImageTypeTestImage:
+		return "Test image"
+	case ImageTypeFXSDK:
+		return "OpenJFX SDK"
+	default:
+		return "JDK"
+	}
+}
+
+// IsRunnableImage reports whether an image type contains bin\java.exe
+// and can therefore be used as JAVA_HOME
+func IsRunnableImage(imageType string) bool {
+	return imageType == "" || imageType == ImageTypeJDK || imageType == ImageTypeJRE
+}
+
+// installDirName returns the directory name for an installation so that
+// different image types of the same version never share a directory
+func installDirName(version string, imageType string) string {
+	switch imageType {
+	case ImageTypeJRE:
+		return "jre-" + version
+	case ImageTypeDebugImage:
+		return "jdk-" + version + "-debugimage"
+	case ImageTypeTestImage:
+		return "jdk-" + version + "-testimage"
+	case ImageTypeFXSDK:
+		return "javafx-sdk-" + version
+	default:
+		return "jdk-" + version
+	}
 }
@@ -1,9 +1,8 @@
 package installer
 
 import (
-	"archive/zip"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,244 +11,711 @@ import (
 	"strings"
 	"time"
 
+	"jv/internal/cache"
+	"jv/internal/config"
+	"jv/internal/hooks"
+	"jv/internal/httpclient"
+	"jv/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// DownloadFile downloads a file from URL with animated progress bar
-func DownloadFile(url string, destPath string) error {
-	out, err := os.Create(destPath)
+// DownloadFile downloads a file from URL with animated progress bar.
+// Data is written to destPath+".part" and renamed when complete, so a dropped
+// connection resumes with an HTTP Range request instead of starting over.
+// Network errors, stalls, 429 and 5xx responses are retried with exponential
+// backoff, failing over between urls, which must all point to the same file.
+// A non-empty checksum is verified while the data streams in; a mirror serving
+// a file with a different digest is skipped. Cancelling ctx or pressing Ctrl+C
+// stops the download and keeps the .part file for resuming.
+func DownloadFile(ctx context.Context, urls []string, destPath string, checksum string, checksumAlgo string) error {
+	sum, err := newStreamChecksum(checksum, checksumAlgo)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return err
 	}
-	defer out.Close()
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var p *tea.Program
+	var pw *progressWriter
+	uiDone := make(chan struct{})
+
+	// Start the progress UI on the first response, when the total size is known
+	onStart := func(offset int64, total int64) io.Writer {
+		if p == nil {
+			p = tea.NewProgram(NewProgressModel(total))
+			pw = newProgressWriter(total, p)
+
+			go func() {
+				defer close(uiDone)
+				final, err := p.Run()
+				if m, ok := final.(ProgressModel); (ok && m.cancelled) || errors.Is(err, tea.ErrInterrupted) {
+					cancel(ErrCancelled)
+				} else if err != nil {
+					fmt.Printf("Error running progress: %v\n", err)
+				}
+			}()
+
+			// Give the UI a moment to start
+			time.Sleep(100 * time.Millisecond)
+		}
+		pw.resume(offset)
+		return pw
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	onRetry := func(attempt int, wait time.Duration, err error) {
+		if p != nil {
+			p.Send(progressRetryMsg{attempt: attempt, wait: wait, err: err})
+		} else {
+			fmt.Printf("Download attempt %d failed: %v (retrying in %s)\n", attempt, err, wait)
+		}
 	}
 
-	totalSize := resp.ContentLength
+	if err := fetchFile(ctx, urls, destPath, sum, onStart, onRetry); err != nil {
+		if p != nil {
+			p.Send(progressErrMsg{err: err})
+			p.Quit()
+			<-uiDone
+		}
+		return err
+	}
 
-	// Create progress model
-	progressModel := NewProgressModel(totalSize)
-	p := tea.NewProgram(progressModel)
+	// Signal completion and let the UI finish drawing
+	p.Send(downloadCompleteMsg{})
+	<-uiDone
 
-	// Create progress writer
-	pw := newProgressWriter(totalSize, p)
+	return nil
+}
+
+// fetchFile downloads the file at the first working URL to destPath through a
+// resumable .part file. URLs are mirrors of the same file: a failing mirror
+// fails over to the next one immediately, and retries back off once every
+// mirror has been tried. sum, if not nil, is verified once the file is complete.
+// onStart is called at the start of every accepted response and returns the
+// progress sink; onRetry is called before each retry. Cancelling ctx stops
+// the download and any wait between retries.
+func fetchFile(ctx context.Context, urls []string, destPath string, sum *streamChecksum, onStart func(offset int64, total int64) io.Writer, onRetry func(attempt int, wait time.Duration, err error)) error {
+	if len(urls) == 0 {
+		return fmt.Errorf("no download URL available")
+	}
+
+	partPath := destPath + partSuffix
+	failed := make(map[int]bool) // Mirrors that returned a permanent error
+	mirror := 0
+	round := 0
 
-	// Start the progress UI in a goroutine
-	go func() {
-		if _, err := p.Run(); err != nil {
-			fmt.Printf("Error running progress: %v\n", err)
+	for attempt := 1; ; attempt++ {
+		err := downloadAttempt(ctx, urls[mirror], partPath, sum, onStart)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
 		}
-	}()
 
-	// Give the UI a moment to start
-	time.Sleep(100 * time.Millisecond)
+		canRetry, serverWait := isRetryable(err)
+		if !canRetry {
+			failed[mirror] = true
+		}
 
-	// Create multi-writer: write to file AND progress tracker
-	multiWriter := io.MultiWriter(out, pw)
+		next := nextMirror(mirror, len(urls), failed)
+		if next < 0 || attempt >= maxDownloadAttempts*len(urls) {
+			return err
+		}
 
-	// Download with progress
-	written, err := io.Copy(multiWriter, resp.Body)
+		// Fail over straight away while untried mirrors remain
+		if next > mirror {
+			onRetry(attempt, 0, fmt.Errorf("%w; trying mirror %d/%d", err, next+1, len(urls)))
+			mirror = next
+			continue
+		}
+
+		mirror = next
+		round++
+		wait := retryDelay(round, serverWait)
+		onRetry(attempt, wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+
+	if err := os.Rename(partPath, destPath); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
+
+	return nil
+}
+
+// nextMirror returns the index of the next mirror to try after current,
+// wrapping around and skipping failed ones, or -1 if none is left
+func nextMirror(current int, count int, failed map[int]bool) int {
+	for step := 1; step <= count; step++ {
+		idx := (current + step) % count
+		if !failed[idx] {
+			return idx
+		}
+	}
+	return -1
+}
+
+// downloadAttempt performs a single request, appending to partPath when the
+// server honours the Range request. onStart is called with the resume offset
+// and total size once the response is accepted and returns the progress sink.
+// A complete file that fails sum is deleted and reported as not retryable, so
+// the next mirror is tried.
+func downloadAttempt(ctx context.Context, url string, partPath string, sum *streamChecksum, onStart func(offset int64, total int64) io.Writer) error {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		p.Send(progressErrMsg{err: err})
-		p.Quit()
-		return fmt.Errorf("failed to write file: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	if written != totalSize {
-		err := fmt.Errorf("incomplete download: got %d bytes, expected %d", written, totalSize)
-		p.Send(progressErrMsg{err: err})
-		p.Quit()
+	// No overall timeout: JDK archives are large, stalls are caught by stallReader
+	client, err := httpclient.Client(0)
+	if err != nil {
 		return err
 	}
 
-	// Signal completion
-	p.Send(downloadCompleteMsg{})
+	resp, err := client.Do(req)
+	if err != nil {
+		return retryable(fmt.Errorf("failed to download: %w", err), 0)
+	}
+	defer resp.Body.Close()
 
-	// Wait a moment for UI to finish
-	time.Sleep(200 * time.Millisecond)
+	flags := os.O_WRONLY | os.O_CREATE
+	var totalSize int64
 
-	return nil
-}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			os.Remove(partPath)
+			return retryable(fmt.Errorf("server returned unexpected range %q", resp.Header.Get("Content-Range")), 0)
+		}
+		totalSize = total
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Server ignored the Range header (or there was nothing to resume)
+		offset = 0
+		totalSize = resp.ContentLength
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Partial file does not match the remote file; start over
+		os.Remove(partPath)
+		return retryable(fmt.Errorf("cannot resume partial download, restarting"), 0)
+	default:
+		err := fmt.Errorf("download failed with status: %d", resp.StatusCode)
+		if isRetryableStatus(resp.StatusCode) {
+			return retryable(err, parseRetryAfter(resp.Header.Get("Retry-After")))
+		}
+		return err
+	}
+
+	// Hash what is already on disk so the digest covers the whole file
+	writers := []io.Writer{}
+	if sum != nil {
+		if err := sum.resume(partPath, offset); err != nil {
+			return err
+		}
+		writers = append(writers, sum.hash)
+	}
 
-// VerifyChecksum verifies the SHA256 checksum of a file
-func VerifyChecksum(filePath string, expectedChecksum string) error {
-	file, err := os.Open(filePath)
+	out, err := os.OpenFile(partPath, flags, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer file.Close()
+	defer out.Close()
 
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return fmt.Errorf("failed to calculate checksum: %w", err)
+	// Create multi-writer: write to file, checksum AND progress tracker
+	multiWriter := io.MultiWriter(append([]io.Writer{out}, append(writers, onStart(offset, totalSize))...)...)
+
+	body := newStallReader(resp.Body, readTimeout, cancel)
+	defer body.Stop()
+
+	// Download with progress
+	written, err := io.Copy(multiWriter, body)
+	if err != nil {
+		if body.err != nil {
+			// Connection dropped or stalled; what we have is kept for resuming
+			return retryable(fmt.Errorf("download interrupted: %w", body.err), 0)
+		}
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	actualChecksum := hex.EncodeToString(hasher.Sum(nil))
-	if !strings.EqualFold(actualChecksum, expectedChecksum) {
-		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksum)
+	if totalSize > 0 && offset+written != totalSize {
+		return retryable(fmt.Errorf("incomplete download: got %d bytes, expected %d", offset+written, totalSize), 0)
+	}
+
+	if sum != nil {
+		if err := sum.verify(); err != nil {
+			out.Close()
+			os.Remove(partPath)
+			return err
+		}
 	}
 
 	return nil
 }
 
-// ExtractZip extracts a ZIP file to the destination directory
-func ExtractZip(zipPath string, destDir string) (string, error) {
-	reader, err := zip.OpenReader(zipPath)
+// InstallJDK orchestrates the download, verification, and extraction of a JDK
+// or any other image type described by downloadInfo. Cancelling ctx stops it
+// and removes everything but the resumable part of the download.
+func InstallJDK(ctx context.Context, downloadInfo *DownloadInfo, version string, distributor string, isSystemWide bool) (*config.InstalledJDK, error) {
+	// Each run gets its own workspace so concurrent installs never collide
+	tempDir, err := newWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	defer closeWorkspace(tempDir)
+
+	zipPath, err := fetchArchive(ctx, downloadInfo, tempDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to open zip: %w", err)
+		return nil, err
+	}
+	return InstallArchive(ctx, zipPath, downloadInfo, version, distributor, isSystemWide)
+}
+
+// fetchArchive returns the path of the archive downloadInfo describes: a
+// previously verified one from the cache, a copy of one on a file share or a
+// fresh download into tempDir, which is verified while it streams in
+func fetchArchive(ctx context.Context, downloadInfo *DownloadInfo, tempDir string) (string, error) {
+	// Reuse a previously verified archive when available
+	if cachedPath := cachedArchive(downloadInfo); cachedPath != "" {
+		fmt.Printf("✓ Using cached archive %s\n", downloadInfo.FileName)
+		return cachedPath, nil
+	}
+
+	if err := ensureFreeSpace(tempDir, downloadInfo.Size); err != nil {
+		return "", err
 	}
-	defer reader.Close()
+	zipPath := filepath.Join(tempDir, downloadInfo.FileName)
 
-	// Find the root directory in the ZIP (usually jdk-xxx)
-	var rootDir string
-	for _, file := range reader.File {
-		if file.FileInfo().IsDir() && strings.HasPrefix(file.Name, "jdk") {
-			parts := strings.Split(file.Name, "/")
-			if len(parts) > 0 {
-				rootDir = parts[0]
-				break
-			}
+	// Archives on a file share are copied, so the cache never takes the
+	// original; the checksum is verified like that of a cached archive
+	if sourcePath, ok := localSourcePath(downloadInfo.URL); ok {
+		fmt.Printf("Copying %s from %s...\n", ImageTypeLabel(downloadInfo.ImageType), sourcePath)
+		if err := copyFile(sourcePath, zipPath, 0644); err != nil {
+			return "", fmt.Errorf("copy failed: %w", err)
 		}
+		return zipPath, nil
 	}
 
-	extractedPath := ""
+	adoptPartial(zipPath)
+	fmt.Printf("Downloading %s...\n", ImageTypeLabel(downloadInfo.ImageType))
+	if err := DownloadFile(ctx, downloadInfo.DownloadURLs(), zipPath, downloadInfo.Checksum, downloadInfo.ChecksumAlgo); err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	downloadInfo.verified = downloadInfo.Checksum != ""
+	return zipPath, nil
+}
 
-	for _, file := range reader.File {
-		filePath := filepath.Join(destDir, file.Name)
+// installBaseDir returns the directory that holds installations for a distributor
+func installBaseDir(distributor string, isSystemWide bool) (string, error) {
+	if isSystemWide {
+		// The name becomes a folder under Program Files and must not lead out of it
+		if err := checkDirName(distributor); err != nil {
+			return "", fmt.Errorf("invalid distributor name: %w", err)
+		}
+		// Use absolute path for system-wide installation
+		return filepath.Join(`C:\Program Files`, distributor), nil
+	}
 
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(filePath, os.ModePerm)
-			continue
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".jv"), nil
+}
+
+// reservedDeviceNames cannot be used as file or folder names on Windows
+var reservedDeviceNames = []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9", "LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
+
+// checkDirName checks that name can be used as a single folder name on
+// Windows: no path separators, no "." or "..", no characters Windows forbids
+// and no reserved device names
+func checkDirName(name string) error {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." {
+		return fmt.Errorf("%q is not a folder name", name)
+	}
+	if strings.ContainsAny(name, `<>:"/\|?*`) {
+		return fmt.Errorf("%q contains a character not allowed in folder names", name)
+	}
+	for _, r := range name {
+		if r < 0x20 {
+			return fmt.Errorf("%q contains a control character", name)
 		}
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("%q ends with a dot or space", name)
+	}
+	stem, _, _ := strings.Cut(name, ".")
+	for _, reserved := range reservedDeviceNames {
+		if strings.EqualFold(strings.TrimSpace(stem), reserved) {
+			return fmt.Errorf("%q is a reserved device name", name)
+		}
+	}
+	return nil
+}
 
-		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			return "", fmt.Errorf("failed to create directory: %w", err)
+// InstallArchive verifies a downloaded archive, extracts it into a staging
+// directory on the target volume and swaps the result into its final
+// installation directory. The new JDK is smoke tested before the swap, and a
+// previous installation at that location is only removed once the new one is
+// in place. An empty version is read from the archive's release file, which
+// also fills in downloadInfo.Release and ImageType. It returns the receipt to
+// record in installed_jdks. Cancelling ctx rolls the installation back unless
+// the new JDK is already in place.
+func InstallArchive(ctx context.Context, zipPath string, downloadInfo *DownloadInfo, version string, distributor string, isSystemWide bool) (receipt *config.InstalledJDK, err error) {
+	if err := verifyArchive(ctx, zipPath, downloadInfo); err != nil {
+		return nil, err
+	}
+
+	// Check the vendor's signature, which unlike the checksum does not come
+	// from the same server as the archive
+	var signature SignatureResult
+	var signatureErr error
+	spinnerErr := WithSpinner(ctx, "Verifying signature...", func(ctx context.Context) error {
+		signature, signatureErr = VerifySignature(ctx, zipPath, downloadInfo, distributor)
+		return nil
+	})
+	if spinnerErr != nil {
+		return nil, spinnerErr
+	}
+	if signatureErr != nil {
+		if errors.Is(signatureErr, ErrBadSignature) {
+			evictFromCache(zipPath, downloadInfo.Checksum)
 		}
+		return nil, signatureErr
+	}
+	switch signature.Status {
+	case SignatureVerified:
+		fmt.Printf("✓ Signature verified (key %s)\n", FormatFingerprint(signature.SignedBy))
+	case SignatureOff:
+		fmt.Println(theme.Faint.Render("Signature verification disabled by policy"))
+	default:
+		fmt.Println(theme.WarningMessage("Signature not verified: " + signature.Reason))
+	}
 
-		outFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
+	stage, err := stageArchive(ctx, zipPath, downloadInfo, distributor, isSystemWide)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
 		if err != nil {
-			return "", fmt.Errorf("failed to create file: %w", err)
+			stage.tx.Rollback()
 		}
+	}()
+	extractedPath := stage.extractedPath
 
-		rc, err := file.Open()
-		if err != nil {
-			outFile.Close()
-			return "", fmt.Errorf("failed to open file in zip: %w", err)
+	// Archives from outside a distributor describe themselves in the release file
+	if version == "" {
+		release := InstalledRelease(extractedPath)
+		if release == "" {
+			return nil, fmt.Errorf("cannot determine the Java version: the archive has no release file")
 		}
+		version = MajorVersion(release)
+		if downloadInfo.Release == "" {
+			downloadInfo.Release = release
+		}
+		if downloadInfo.ImageType == "" {
+			downloadInfo.ImageType = detectImageType(extractedPath)
+		}
+	}
+	if downloadInfo.Arch == "" {
+		downloadInfo.Arch = JDKArch(extractedPath)
+	}
 
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
+	// Keep verified downloads for reinstalls and other scopes, once the
+	// version, package type and architecture are known. Local archives have
+	// no URL and stay where the user put them; unverified ones are not cached
+	// since the cache is indexed by checksum.
+	source := downloadInfo.URL
+	if source == "" {
+		source = zipPath
+	} else if downloadInfo.Checksum != "" {
+		storeInCache(zipPath, downloadInfo, version, distributor)
+	}
 
-		if err != nil {
-			return "", fmt.Errorf("failed to extract file: %w", err)
+	// Verify the java launcher exists (debug and test images carry none).
+	// Tarballs built for Linux or macOS ship bin/java without the extension.
+	if IsRunnableImage(downloadInfo.ImageType) {
+		_, exeErr := os.Stat(filepath.Join(extractedPath, "bin", "java.exe"))
+		_, binErr := os.Stat(filepath.Join(extractedPath, "bin", "java"))
+		if exeErr != nil && binErr != nil {
+			return nil, fmt.Errorf("invalid %s structure: bin\\java.exe not found", ImageTypeLabel(downloadInfo.ImageType))
 		}
 	}
 
-	if rootDir != "" {
-		extractedPath = filepath.Join(destDir, rootDir)
+	// Run the new JDK before it replaces anything. Builds for an architecture
+	// this machine cannot run, e.g. ARM64 on x64, are installed untested.
+	smoke := SmokeResult{Status: SmokeSkipped}
+	if CanRunArch(downloadInfo.Arch) {
+		var smokeErr error
+		spinnerErr = WithSpinner(ctx, "Running smoke test...", func(ctx context.Context) error {
+			smoke, smokeErr = SmokeTest(ctx, extractedPath, downloadInfo.ImageType, version, downloadInfo.Release, filepath.Join(stage.tx.stagingDir, "smoke"))
+			return nil
+		})
+		if spinnerErr != nil {
+			return nil, spinnerErr
+		}
+		if smokeErr != nil {
+			return nil, fmt.Errorf("smoke test failed: %w", smokeErr)
+		}
+	} else {
+		fmt.Printf("Skipping smoke test: %s builds cannot run on this %s machine\n", ArchLabel(downloadInfo.Arch), ArchLabel(HostArch()))
+	}
+	switch smoke.Status {
+	case SmokePassed:
+		fmt.Printf("✓ Smoke test passed (java %s, javac compile and run)\n", smoke.FullVersion)
+	case SmokeVersionOnly:
+		fmt.Printf("✓ Smoke test passed (java %s)\n", smoke.FullVersion)
 	}
 
-	return extractedPath, nil
-}
+	finalPath := stage.finalPath(version)
 
-// InstallJDK orchestrates the download, verification, and extraction of a JDK
-func InstallJDK(downloadInfo *DownloadInfo, version string, distributor string, isSystemWide bool) (string, error) {
-	// Determine installation base directory
-	var installBase string
+	// Archives installed from the offline cache carry no release, read it from the JDK
+	release := downloadInfo.Release
+	if release == "" {
+		release = InstalledRelease(extractedPath)
+	}
+	scope := "user"
 	if isSystemWide {
-		// Use absolute path for system-wide installation
-		installBase = filepath.Join(`C:\Program Files`, distributor)
-	} else {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
-		}
-		installBase = filepath.Join(homeDir, ".jv")
+		scope = "system"
 	}
 
-	// Create installation directory
-	if err := os.MkdirAll(installBase, 0755); err != nil {
-		return "", fmt.Errorf("failed to create installation directory: %w", err)
+	// A failing pre-install hook aborts before anything is replaced. It sees
+	// the staged JDK, since finalPath is only filled by the commit.
+	hookCtx := hooks.Context{
+		Path:       extractedPath,
+		TargetPath: finalPath,
+		Version:    version,
+		Release:    release,
+		Vendor:     distributor,
+		Scope:      scope,
+		ImageType:  downloadInfo.ImageType,
+		Arch:       ArchLabel(downloadInfo.Arch),
+	}
+	if err := hooks.Run(ctx, hooks.PreInstall, hookCtx); err != nil {
+		return nil, err
 	}
 
-	// Create temp directory for download
-	tempDir := filepath.Join(os.TempDir(), "jv-install")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	if err := stage.commit(ctx, finalPath); err != nil {
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Download JDK
-	zipPath := filepath.Join(tempDir, downloadInfo.FileName)
-	fmt.Println("Downloading JDK...")
-	if err := DownloadFile(downloadInfo.URL, zipPath); err != nil {
-		return "", fmt.Errorf("download failed: %w", err)
+	hookCtx.Path = finalPath
+	if err := hooks.Run(ctx, hooks.PostInstall, hookCtx); err != nil {
+		fmt.Println(theme.WarningMessage(err.Error()))
 	}
 
-	// Verify checksum with spinner
-	var checksumErr error
-	spinnerErr := WithSpinner("Verifying checksum...", func() error {
-		checksumErr = VerifyChecksum(zipPath, downloadInfo.Checksum)
-		return nil
-	})
-	if spinnerErr != nil {
-		return "", spinnerErr
+	return &config.InstalledJDK{
+		Version:      version,
+		Release:      release,
+		Path:         finalPath,
+		Distributor:  distributor,
+		InstalledAt:  time.Now().Format(time.RFC3339),
+		Scope:        scope,
+		ImageType:    downloadInfo.ImageType,
+		FullVersion:  smoke.FullVersion,
+		Checksum:     downloadInfo.Checksum,
+		ChecksumAlgo: downloadInfo.ChecksumAlgo,
+		Source:       source,
+		Size:         DirSize(finalPath),
+		Arch:         downloadInfo.Arch,
+		SmokeTest:    smoke.Status,
+		Signature:    signature.Status,
+		SignedBy:     signature.SignedBy,
+		EarlyAccess:  downloadInfo.EarlyAccess,
+	}, nil
+}
+
+// verifyArchive checks the checksum of an archive. Fresh downloads were
+// verified while streaming; cached and local archives are checked here, and
+// a cached one that fails is evicted.
+func verifyArchive(ctx context.Context, zipPath string, downloadInfo *DownloadInfo) error {
+	label := ChecksumLabel(downloadInfo.ChecksumAlgo)
+	switch {
+	case downloadInfo.Checksum == "":
+		fmt.Println(theme.WarningMessage("Checksum verification skipped (--insecure-skip-checksum)"))
+	case downloadInfo.verified:
+		fmt.Printf("✓ %s checksum verified during download\n", label)
+	default:
+		var checksumErr error
+		spinnerErr := WithSpinner(ctx, "Verifying checksum...", func(context.Context) error {
+			checksumErr = VerifyChecksum(zipPath, downloadInfo.Checksum, downloadInfo.ChecksumAlgo)
+			return nil
+		})
+		if spinnerErr != nil {
+			return spinnerErr
+		}
+		if checksumErr != nil {
+			evictFromCache(zipPath, downloadInfo.Checksum)
+			return fmt.Errorf("checksum verification failed: %w", checksumErr)
+		}
+		fmt.Printf("✓ %s checksum verified successfully\n", label)
 	}
-	if checksumErr != nil {
-		return "", fmt.Errorf("checksum verification failed: %w", checksumErr)
+	return nil
+}
+
+// stagedInstall is an archive extracted into a staging directory next to the
+// installations of its distributor, waiting to be swapped into place
+type stagedInstall struct {
+	tx            *installTransaction
+	info          *DownloadInfo
+	installBase   string
+	extractedPath string // Top-level directory of the extracted archive
+}
+
+// stageArchive extracts a verified archive into a staging directory on the
+// volume it is installed to, after checking that it fits. Callers roll
+// stage.tx back unless commit succeeds.
+func stageArchive(ctx context.Context, zipPath string, downloadInfo *DownloadInfo, distributor string, isSystemWide bool) (*stagedInstall, error) {
+	installBase, err := installBaseDir(distributor, isSystemWide)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(installBase, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create installation directory: %w", err)
+	}
+
+	// Make sure the extracted files fit before touching the installation directory
+	if err := ensureFreeSpace(installBase, extractedSize(zipPath, downloadInfo.FileName)); err != nil {
+		return nil, err
+	}
+
+	// Stage on the target volume so the final move is a rename
+	tx, err := beginInstall(installBase)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("✓ Checksum verified successfully")
 
-	// Extract to temp location with spinner
 	var extractedPath string
 	var extractErr error
-	tempExtractDir := filepath.Join(tempDir, "extract")
-
-	spinnerErr = WithSpinner("Extracting JDK...", func() error {
-		var err error
-		extractedPath, err = ExtractZip(zipPath, tempExtractDir)
-		extractErr = err
+	spinnerErr := WithSpinner(ctx, fmt.Sprintf("Extracting %s...", ImageTypeLabel(downloadInfo.ImageType)), func(ctx context.Context) error {
+		extractedPath, extractErr = ExtractArchive(ctx, zipPath, filepath.Join(tx.stagingDir, "extract"), downloadInfo.FileName)
 		return nil
 	})
 	if spinnerErr != nil {
-		return "", spinnerErr
+		tx.Rollback()
+		return nil, spinnerErr
 	}
 	if extractErr != nil {
-		return "", fmt.Errorf("extraction failed: %w", extractErr)
+		tx.Rollback()
+		return nil, fmt.Errorf("extraction failed: %w", extractErr)
 	}
-	fmt.Println("✓ JDK extracted successfully")
+	fmt.Printf("✓ %s extracted successfully\n", ImageTypeLabel(downloadInfo.ImageType))
+
+	return &stagedInstall{tx: tx, info: downloadInfo, installBase: installBase, extractedPath: extractedPath}, nil
+}
 
-	// Verify java.exe exists
-	javaExe := filepath.Join(extractedPath, "bin", "java.exe")
-	if _, err := os.Stat(javaExe); os.IsNotExist(err) {
-		return "", fmt.Errorf("invalid JDK structure: bin\\java.exe not found")
+// finalPath returns the installation directory for the staged archive. It is
+// named after the full release when known, so patch releases of the same
+// major version can be installed side by side.
+func (s *stagedInstall) finalPath(version string) string {
+	dirVersion := version
+	if s.info.Release != "" {
+		dirVersion = s.info.Release
+	}
+	// Early-access builds never share a directory with a GA release
+	if s.info.EarlyAccess && !strings.Contains(strings.ToLower(dirVersion), "-ea") {
+		dirVersion += "-ea"
+	}
+	// Builds for another architecture get their own directory
+	if s.info.Arch != "" && s.info.Arch != HostArch() {
+		dirVersion += "-" + ArchLabel(s.info.Arch)
 	}
+	return filepath.Join(s.installBase, installDirName(dirVersion, s.info.ImageType))
+}
 
-	// Move to final location
-	finalPath := filepath.Join(installBase, fmt.Sprintf("jdk-%s", version))
+// commit swaps the staged installation in at finalPath; an installation
+// already there is kept until this succeeds. The lock keeps concurrent jv
+// processes from swapping the same path, and nothing is replaced once the
+// user has cancelled.
+func (s *stagedInstall) commit(ctx context.Context, finalPath string) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
 
-	// Remove old installation if exists
-	if _, err := os.Stat(finalPath); err == nil {
-		fmt.Printf("Removing existing installation at %s\n", finalPath)
-		if err := os.RemoveAll(finalPath); err != nil {
-			return "", fmt.Errorf("failed to remove old installation: %w", err)
-		}
+	lock, err := lockInstallPath(finalPath)
+	if err != nil {
+		return err
+	}
+	err = s.tx.Commit(s.extractedPath, finalPath)
+	if err == nil {
+		s.tx.Finish()
+	}
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s installed successfully to: %s\n", ImageTypeLabel(s.info.ImageType), finalPath)
+	return nil
+}
+
+// cachedArchive returns the path of a cached archive matching downloadInfo's checksum, or ""
+func cachedArchive(downloadInfo *DownloadInfo) string {
+	c, err := cache.Open()
+	if err != nil {
+		return ""
+	}
+
+	entry := c.Lookup(downloadInfo.Checksum)
+	if entry == nil {
+		return ""
+	}
+	return c.Path(*entry)
+}
+
+// storeInCache moves a verified archive into the cache and returns its new path.
+// If the cache cannot be used the original path is returned unchanged.
+func storeInCache(archivePath string, downloadInfo *DownloadInfo, version string, distributor string) string {
+	c, err := cache.Open()
+	if err != nil {
+		fmt.Printf("Warning: archive cache unavailable: %v\n", err)
+		return archivePath
 	}
 
-	// Move extracted directory to final location
-	if err := os.Rename(extractedPath, finalPath); err != nil {
-		return "", fmt.Errorf("failed to move JDK to final location: %w", err)
+	if c.Contains(archivePath) {
+		c.Touch(downloadInfo.Checksum)
+		return archivePath
 	}
 
-	fmt.Printf("JDK installed successfully to: %s\n", finalPath)
-	return finalPath, nil
+	cachedPath, err := c.Add(archivePath, cache.Entry{
+		Checksum:     downloadInfo.Checksum,
+		ChecksumAlgo: downloadInfo.ChecksumAlgo,
+		FileName:     downloadInfo.FileName,
+		URL:          downloadInfo.URL,
+		Distributor:  distributor,
+		Version:      version,
+		ImageType:    downloadInfo.ImageType,
+		Arch:         downloadInfo.Arch,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to cache archive: %v\n", err)
+		return archivePath
+	}
+	return cachedPath
+}
+
+// evictFromCache drops a cached archive that failed verification
+func evictFromCache(archivePath string, checksum string) {
+	c, err := cache.Open()
+	if err != nil || !c.Contains(archivePath) {
+		return
+	}
+	c.Remove(checksum)
 }
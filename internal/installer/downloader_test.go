@@ -0,0 +1,340 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckDirName(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr string // Substring of the expected error; empty for success
+	}{
+		{name: "plain", dir: "Eclipse Adoptium"},
+		{name: "dotted", dir: "Company.JDK"},
+		{name: "empty", dir: "", wantErr: "is not a folder name"},
+		{name: "blank", dir: "   ", wantErr: "is not a folder name"},
+		{name: "dot", dir: ".", wantErr: "is not a folder name"},
+		{name: "parent", dir: "..", wantErr: "is not a folder name"},
+		{name: "backslash", dir: `..\Windows`, wantErr: "character not allowed"},
+		{name: "slash", dir: "a/b", wantErr: "character not allowed"},
+		{name: "drive", dir: `C:\Windows`, wantErr: "character not allowed"},
+		{name: "wildcard", dir: "jdk*", wantErr: "character not allowed"},
+		{name: "control character", dir: "jdk\t21", wantErr: "control character"},
+		{name: "trailing dot", dir: "jdk.", wantErr: "ends with a dot or space"},
+		{name: "trailing space", dir: "jdk ", wantErr: "ends with a dot or space"},
+		{name: "device name", dir: "NUL", wantErr: "reserved device name"},
+		{name: "device name with extension", dir: "com1.jdk", wantErr: "reserved device name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDirName(tt.dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkDirName(%q): %v", tt.dir, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkDirName(%q) error = %v, want error containing %q", tt.dir, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// downloadServer serves content like a release host, honouring Range
+// requests. fail, if not nil, may answer a request instead, given its
+// 1-based number.
+type downloadServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests int
+	ranges   []string // Range header of each request
+}
+
+func newDownloadServer(t *testing.T, content []byte, fail func(n int, w http.ResponseWriter, r *http.Request) bool) *downloadServer {
+	t.Helper()
+	s := &downloadServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		n := s.requests
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		s.mu.Unlock()
+
+		if fail != nil && fail(n, w, r) {
+			return
+		}
+		http.ServeContent(w, r, "jdk.zip", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// testArchive returns archive-like content and its SHA-256 digest
+func testArchive() ([]byte, string) {
+	content := bytes.Repeat([]byte("PK\x03\x04 jdk archive bytes "), 4096)
+	digest := sha256.Sum256(content)
+	return content, hex.EncodeToString(digest[:])
+}
+
+// fetchTestFile runs fetchFile without a progress UI
+func fetchTestFile(t *testing.T, urls []string, destPath string, checksum string) (retries []error, err error) {
+	t.Helper()
+	sum, err := newStreamChecksum(checksum, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onStart := func(offset int64, total int64) io.Writer { return io.Discard }
+	onRetry := func(attempt int, wait time.Duration, err error) { retries = append(retries, err) }
+	err = fetchFile(context.Background(), urls, destPath, sum, onStart, onRetry)
+	return retries, err
+}
+
+func assertFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("download not written: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("download has %d bytes that differ from the %d served", len(got), len(want))
+	}
+}
+
+func TestDownloadFileResumesPartialDownload(t *testing.T) {
+	content, digest := testArchive()
+	server := newDownloadServer(t, content, nil)
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	offset := len(content) / 2
+	if err := os.WriteFile(destPath+partSuffix, content[:offset], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DownloadFile(context.Background(), []string{server.URL + "/jdk.zip"}, destPath, digest, ChecksumSHA256); err != nil {
+		t.Fatalf("DownloadFile(): %v", err)
+	}
+
+	assertFile(t, destPath, content)
+	assertMissing(t, destPath+partSuffix)
+	if want := fmt.Sprintf("bytes=%d-", offset); len(server.ranges) != 1 || server.ranges[0] != want {
+		t.Errorf("Range headers sent = %q, want [%q]", server.ranges, want)
+	}
+}
+
+func TestFetchFileRestartsWhenRangeIgnored(t *testing.T) {
+	content, digest := testArchive()
+	// The server sends the whole file even though a Range was requested
+	server := newDownloadServer(t, content, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		w.Write(content)
+		return true
+	})
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	if err := os.WriteFile(destPath+partSuffix, []byte("bytes of another file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fetchTestFile(t, []string{server.URL}, destPath, digest); err != nil {
+		t.Fatalf("fetchFile(): %v", err)
+	}
+	assertFile(t, destPath, content)
+}
+
+func TestFetchFileResumesAfterDroppedConnection(t *testing.T) {
+	content, digest := testArchive()
+	sent := len(content) / 3
+	server := newDownloadServer(t, content, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		if n > 1 {
+			return false
+		}
+		// Announce the whole file, send a third of it and hang up
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content[:sent])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return true
+	})
+
+	// The same server twice, so the retry fails over without a backoff
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	retries, err := fetchTestFile(t, []string{server.URL + "/a", server.URL + "/b"}, destPath, digest)
+	if err != nil {
+		t.Fatalf("fetchFile(): %v", err)
+	}
+
+	assertFile(t, destPath, content)
+	if len(retries) != 1 {
+		t.Errorf("fetchFile() retried %d times, want once: %v", len(retries), retries)
+	}
+	if want := fmt.Sprintf("bytes=%d-", sent); len(server.ranges) != 2 || server.ranges[1] != want {
+		t.Errorf("Range headers sent = %q, want a resume with %q", server.ranges, want)
+	}
+}
+
+func TestFetchFileRetriesServerErrors(t *testing.T) {
+	content, digest := testArchive()
+	server := newDownloadServer(t, content, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		if n > 1 {
+			return false
+		}
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return true
+	})
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	retries, err := fetchTestFile(t, []string{server.URL}, destPath, digest)
+	if err != nil {
+		t.Fatalf("fetchFile(): %v", err)
+	}
+
+	assertFile(t, destPath, content)
+	if len(retries) != 1 || !strings.Contains(retries[0].Error(), "status: 503") {
+		t.Errorf("retries = %v, want one after the 503", retries)
+	}
+}
+
+func TestFetchFileSkipsMirrorWithWrongChecksum(t *testing.T) {
+	content, digest := testArchive()
+	tampered := append([]byte("tampered"), content[8:]...)
+	bad := newDownloadServer(t, tampered, nil)
+	good := newDownloadServer(t, content, nil)
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	retries, err := fetchTestFile(t, []string{bad.URL, good.URL}, destPath, digest)
+	if err != nil {
+		t.Fatalf("fetchFile(): %v", err)
+	}
+
+	assertFile(t, destPath, content)
+	if len(retries) != 1 || !errors.Is(retries[0], ErrChecksumMismatch) {
+		t.Errorf("retries = %v, want one checksum mismatch", retries)
+	}
+	// The tampered bytes must not be resumed from
+	if good.ranges[0] != "" {
+		t.Errorf("second mirror was asked for range %q, want the whole file", good.ranges[0])
+	}
+	if bad.requests != 1 {
+		t.Errorf("mirror with the wrong file was asked %d times, want once", bad.requests)
+	}
+}
+
+func TestFetchFileRestartsUnsatisfiableRange(t *testing.T) {
+	content, digest := testArchive()
+	server := newDownloadServer(t, content, nil)
+
+	// A part file longer than the remote file cannot be resumed
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	if err := os.WriteFile(destPath+partSuffix, append(append([]byte{}, content...), "trailing"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	retries, err := fetchTestFile(t, []string{server.URL + "/a", server.URL + "/b"}, destPath, digest)
+	if err != nil {
+		t.Fatalf("fetchFile(): %v", err)
+	}
+	assertFile(t, destPath, content)
+	if len(retries) != 1 || !strings.Contains(retries[0].Error(), "cannot resume") {
+		t.Errorf("retries = %v, want one restart", retries)
+	}
+}
+
+func TestFetchFileGivesUpOnPermanentErrors(t *testing.T) {
+	content, digest := testArchive()
+	notFound := func(n int, w http.ResponseWriter, r *http.Request) bool {
+		http.NotFound(w, r)
+		return true
+	}
+	first := newDownloadServer(t, content, notFound)
+	second := newDownloadServer(t, content, notFound)
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	_, err := fetchTestFile(t, []string{first.URL, second.URL}, destPath, digest)
+	if err == nil || !strings.Contains(err.Error(), "status: 404") {
+		t.Fatalf("fetchFile() error = %v, want status 404", err)
+	}
+	if first.requests != 1 || second.requests != 1 {
+		t.Errorf("mirrors were asked %d and %d times, want once each", first.requests, second.requests)
+	}
+	assertMissing(t, destPath)
+}
+
+func TestFetchFileCancelled(t *testing.T) {
+	content, digest := testArchive()
+	server := newDownloadServer(t, content, func(n int, w http.ResponseWriter, r *http.Request) bool {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return true
+	})
+
+	sum, err := newStreamChecksum(digest, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	onStart := func(offset int64, total int64) io.Writer { return io.Discard }
+	// Cancel while waiting to retry
+	onRetry := func(attempt int, wait time.Duration, err error) { cancel(ErrCancelled) }
+
+	destPath := filepath.Join(t.TempDir(), "jdk.zip")
+	start := time.Now()
+	err = fetchFile(ctx, []string{server.URL}, destPath, sum, onStart, onRetry)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("fetchFile() error = %v, want ErrCancelled", err)
+	}
+	if elapsed := time.Since(start); elapsed >= initialRetryBackoff {
+		t.Errorf("fetchFile() took %s to stop, want less than the retry backoff", elapsed)
+	}
+}
+
+func TestStagedInstallFinalPath(t *testing.T) {
+	base := filepath.FromSlash("/jdks")
+	other := ArchX86
+	if HostArch() == ArchX86 {
+		other = ArchX64
+	}
+
+	tests := []struct {
+		name string
+		info DownloadInfo
+		want string
+	}{
+		{name: "major version only", info: DownloadInfo{}, want: "jdk-21"},
+		{name: "release", info: DownloadInfo{Release: "21.0.5+11"}, want: "jdk-21.0.5+11"},
+		{name: "JRE", info: DownloadInfo{Release: "21.0.5+11", ImageType: ImageTypeJRE}, want: "jre-21.0.5+11"},
+		{name: "early access", info: DownloadInfo{Release: "24+20", EarlyAccess: true}, want: "jdk-24+20-ea"},
+		{name: "early access named so", info: DownloadInfo{Release: "24-ea+20", EarlyAccess: true}, want: "jdk-24-ea+20"},
+		{name: "host architecture", info: DownloadInfo{Release: "21.0.5+11", Arch: HostArch()}, want: "jdk-21.0.5+11"},
+		{name: "other architecture", info: DownloadInfo{Release: "21.0.5+11", Arch: other}, want: "jdk-21.0.5+11-" + ArchLabel(other)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := &stagedInstall{info: &tt.info, installBase: base}
+			if got := stage.finalPath("21"); got != filepath.Join(base, tt.want) {
+				t.Errorf("finalPath() = %s, want %s", got, filepath.Join(base, tt.want))
+			}
+		})
+	}
+}
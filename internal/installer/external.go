@@ -0,0 +1,588 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"jv/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxIndexSize bounds a release index file
+	maxIndexSize = 16 << 20
+
+	// pluginTimeout bounds a single call to a distributor plugin
+	pluginTimeout = 2 * time.Minute
+
+	// pluginProtocol is the version of the plugin protocol sent with every request
+	pluginProtocol = 1
+)
+
+// externalPackage is one downloadable archive in a release index or a plugin
+// response. Relative URLs in an index are resolved against the index location.
+type externalPackage struct {
+	Arch         string `json:"arch,omitempty" yaml:"arch"`                   // e.g. "x64" (default) or "aarch64"
+	ImageType    string `json:"image_type,omitempty" yaml:"image_type"`       // "jdk" (default), "jre", "debugimage" or "testimage"
+	URL          string `json:"url" yaml:"url"`                               // HTTP(S) URL, file:// URL or path on a file share
+	FileName     string `json:"file_name,omitempty" yaml:"file_name"`         // Defaults to the last segment of URL
+	Checksum     string `json:"checksum,omitempty" yaml:"checksum"`           // Hex digest of the archive
+	ChecksumAlgo string `json:"checksum_algo,omitempty" yaml:"checksum_algo"` // sha1, sha256 or sha512; guessed from the digest if empty
+	ChecksumURL  string `json:"checksum_url,omitempty" yaml:"checksum_url"`   // Checksum file, used when Checksum is empty
+	SignatureURL string `json:"signature_url,omitempty" yaml:"signature_url"` // Detached OpenPGP signature
+	Size         int64  `json:"size,omitempty" yaml:"size"`
+	Release      string `json:"release,omitempty" yaml:"release"` // Full release version, overrides the release's
+}
+
+// externalRelease is one release listed by an index or a plugin
+type externalRelease struct {
+	Version  string            `json:"version" yaml:"version"`           // Major version, e.g. "21"; derived from Release if empty
+	Release  string            `json:"release,omitempty" yaml:"release"` // Full release version, e.g. "21.0.5+11"
+	LTS      bool              `json:"lts,omitempty" yaml:"lts"`
+	EA       bool              `json:"ea,omitempty" yaml:"ea"`     // Early-access build, only offered with --ea
+	Date     string            `json:"date,omitempty" yaml:"date"` // Publication date, e.g. "2024-10-16"
+	NotesURL string            `json:"notes_url,omitempty" yaml:"notes_url"`
+	CVEs     []string          `json:"cves,omitempty" yaml:"cves"`         // Vulnerabilities fixed by the release
+	Packages []externalPackage `json:"packages,omitempty" yaml:"packages"` // Archives of this release; unused in plugin listings
+}
+
+// major returns the major version a release belongs to
+func (r externalRelease) major() string {
+	if r.Version != "" {
+		return r.Version
+	}
+	return MajorVersion(r.Release)
+}
+
+// notes returns the release notes an index or plugin lists for the release,
+// or nil if it lists none
+func (r externalRelease) notes() *ReleaseNotes {
+	if r.Date == "" && r.NotesURL == "" && len(r.CVEs) == 0 {
+		return nil
+	}
+
+	notes := &ReleaseNotes{Release: r.Release, URL: r.NotesURL, CVEs: collectCVEs(r.CVEs...)}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if date, err := time.Parse(layout, r.Date); err == nil {
+			notes.Date = date
+			break
+		}
+	}
+	return notes
+}
+
+// releaseIndex is the content of a release index file
+type releaseIndex struct {
+	Releases []externalRelease `json:"releases" yaml:"releases"`
+}
+
+// NewExternalDistributor creates the distributor an external_distributors
+// entry describes
+func NewExternalDistributor(settings config.ExternalDistributor) (Distributor, error) {
+	id := strings.ToLower(strings.TrimSpace(settings.ID))
+	if id == "" {
+		return nil, fmt.Errorf("external distributor without an id")
+	}
+	if id == AdoptiumID || strings.EqualFold(id, CustomDistributor) {
+		return nil, fmt.Errorf("external distributor id %q is reserved", settings.ID)
+	}
+	if err := checkDirName(id); err != nil {
+		return nil, fmt.Errorf("external distributor id: %w", err)
+	}
+	name := settings.Name
+	if name == "" {
+		name = settings.ID
+	}
+	// System-wide installs go into a Program Files folder named after it
+	if err := checkDirName(name); err != nil {
+		return nil, fmt.Errorf("external distributor %s: invalid name: %w", settings.ID, err)
+	}
+
+	switch {
+	case settings.Index != "" && settings.Command != "":
+		return nil, fmt.Errorf("external distributor %s sets both index and command", settings.ID)
+	case settings.Index != "":
+		return &IndexDistributor{id: id, name: name, location: settings.Index}, nil
+	case settings.Command != "":
+		return &PluginDistributor{id: id, name: name, command: settings.Command, args: settings.Args}, nil
+	}
+	return nil, fmt.Errorf("external distributor %s needs an index or a command", settings.ID)
+}
+
+// IndexDistributor offers the releases listed in a JSON or YAML index file on
+// an HTTP server or a file share
+type IndexDistributor struct {
+	id       string
+	name     string
+	location string // URL or path of the index
+
+	mu    sync.Mutex
+	index *releaseIndex // Loaded on first use
+}
+
+// ID returns the distributor config key
+func (d *IndexDistributor) ID() string {
+	return d.id
+}
+
+// Name returns the distributor name
+func (d *IndexDistributor) Name() string {
+	return d.name
+}
+
+// GetAvailableVersions lists the major versions of the GA releases in the index
+func (d *IndexDistributor) GetAvailableVersions(ctx context.Context) ([]JavaRelease, error) {
+	return d.versions(ctx, false)
+}
+
+// GetEarlyAccessVersions lists the major versions of the early-access
+// releases in the index
+func (d *IndexDistributor) GetEarlyAccessVersions(ctx context.Context) ([]JavaRelease, error) {
+	return d.versions(ctx, true)
+}
+
+// GetDownloadURL returns the newest GA release of a major version that has a
+// package for the architecture and image type
+func (d *IndexDistributor) GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return d.find(ctx, version, arch, imageType, false)
+}
+
+// GetEarlyAccessDownloadURL returns the newest early-access release of a
+// major version that has a package for the architecture and image type
+func (d *IndexDistributor) GetEarlyAccessDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return d.find(ctx, version, arch, imageType, true)
+}
+
+// GetReleaseNotes returns the notes listed for a release, or for the newest
+// GA release of a major version
+func (d *IndexDistributor) GetReleaseNotes(ctx context.Context, version string) (*ReleaseNotes, error) {
+	index, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	isMajor := MajorVersion(version) == version
+	var found *externalRelease
+	for idx := range index.Releases {
+		r := &index.Releases[idx]
+		switch {
+		case isMajor && (r.EA || r.major() != version):
+			continue
+		case !isMajor && (r.Release == "" || CompareReleases(r.Release, version) != 0):
+			continue
+		}
+		if found == nil || CompareReleases(r.Release, found.Release) > 0 {
+			found = r
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s lists no release %s", d.name, version)
+	}
+
+	if notes := found.notes(); notes != nil {
+		return notes, nil
+	}
+	return &ReleaseNotes{Release: found.Release}, nil
+}
+
+// versions lists the major versions of either the GA or the early-access releases
+func (d *IndexDistributor) versions(ctx context.Context, earlyAccess bool) ([]JavaRelease, error) {
+	index, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []externalRelease
+	for _, r := range index.Releases {
+		if r.EA == earlyAccess {
+			list = append(list, r)
+		}
+	}
+	return groupReleases(list), nil
+}
+
+// find returns the newest GA or early-access release of a major version that
+// has a package for the architecture and image type
+func (d *IndexDistributor) find(ctx context.Context, version string, arch string, imageType string, earlyAccess bool) (*DownloadInfo, error) {
+	index, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if imageType == "" {
+		imageType = ImageTypeJDK
+	}
+
+	var best *externalPackage
+	bestRelease := ""
+	for _, r := range index.Releases {
+		if r.major() != version || r.EA != earlyAccess {
+			continue
+		}
+		for idx := range r.Packages {
+			pkg := &r.Packages[idx]
+			if !pkg.matches(arch, imageType) {
+				continue
+			}
+			release := pkg.Release
+			if release == "" {
+				release = r.Release
+			}
+			if best == nil || CompareReleases(release, bestRelease) > 0 {
+				best = pkg
+				bestRelease = release
+			}
+		}
+	}
+	if best == nil {
+		if earlyAccess {
+			return nil, fmt.Errorf("no early-access %s found for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+		}
+		return nil, fmt.Errorf("no %s found for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+	}
+
+	pkg := *best
+	pkg.Release = bestRelease
+	pkg.URL = resolveIndexRef(d.location, pkg.URL)
+	pkg.ChecksumURL = resolveIndexRef(d.location, pkg.ChecksumURL)
+	pkg.SignatureURL = resolveIndexRef(d.location, pkg.SignatureURL)
+
+	info, err := pkg.downloadInfo(ctx, arch, imageType)
+	if err != nil {
+		return nil, err
+	}
+	info.EarlyAccess = earlyAccess
+	return info, nil
+}
+
+// load reads and parses the index once per run
+func (d *IndexDistributor) load(ctx context.Context) (*releaseIndex, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.index != nil {
+		return d.index, nil
+	}
+
+	data, err := fetchLimited(ctx, d.location, maxIndexSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release index %s: %w", d.location, err)
+	}
+
+	// JSON documents are also YAML, but the JSON decoder gives better errors
+	var index releaseIndex
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, &index)
+	} else {
+		err = yaml.Unmarshal(data, &index)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release index %s: %w", d.location, err)
+	}
+
+	d.index = &index
+	return d.index, nil
+}
+
+// resolveIndexRef resolves a URL or path listed in an index against the
+// location of the index
+func resolveIndexRef(indexLocation string, ref string) string {
+	// Drive letters parse as one-letter schemes
+	if u, err := url.Parse(ref); ref == "" || (err == nil && len(u.Scheme) > 1) {
+		return ref
+	}
+	if filepath.VolumeName(ref) != "" || strings.HasPrefix(ref, `\\`) {
+		return ref
+	}
+
+	if indexPath, ok := localSourcePath(indexLocation); ok {
+		if filepath.IsAbs(ref) {
+			return ref
+		}
+		return filepath.Join(filepath.Dir(indexPath), filepath.FromSlash(ref))
+	}
+	base, err := url.Parse(indexLocation)
+	if err != nil {
+		return ref
+	}
+	rel, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(rel).String()
+}
+
+// PluginDistributor asks an external executable for releases and downloads.
+// Each call runs the command with a JSON request on stdin and reads a JSON
+// response from stdout.
+type PluginDistributor struct {
+	id      string
+	name    string
+	command string
+	args    []string
+}
+
+// pluginRequest is written to a plugin's stdin
+type pluginRequest struct {
+	Protocol  int    `json:"protocol"`
+	Action    string `json:"action"` // "list" or "download"
+	Version   string `json:"version,omitempty"`
+	Arch      string `json:"arch,omitempty"` // e.g. "x64"
+	ImageType string `json:"image_type,omitempty"`
+	OS        string `json:"os,omitempty"`
+	EA        bool   `json:"ea,omitempty"` // Ask for early-access builds instead of GA releases
+}
+
+// pluginResponse is read from a plugin's stdout
+type pluginResponse struct {
+	Error    string            `json:"error,omitempty"`
+	Releases []externalRelease `json:"releases,omitempty"` // Answer to "list"
+	Package  *externalPackage  `json:"package,omitempty"`  // Answer to "download"
+}
+
+// ID returns the distributor config key
+func (p *PluginDistributor) ID() string {
+	return p.id
+}
+
+// Name returns the distributor name
+func (p *PluginDistributor) Name() string {
+	return p.name
+}
+
+// GetAvailableVersions asks the plugin for the major versions it offers
+func (p *PluginDistributor) GetAvailableVersions(ctx context.Context) ([]JavaRelease, error) {
+	return p.versions(ctx, false)
+}
+
+// GetEarlyAccessVersions asks the plugin for the major versions it offers
+// early-access builds of
+func (p *PluginDistributor) GetEarlyAccessVersions(ctx context.Context) ([]JavaRelease, error) {
+	return p.versions(ctx, true)
+}
+
+// GetDownloadURL asks the plugin for the newest archive of a major version
+func (p *PluginDistributor) GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return p.download(ctx, version, arch, imageType, false)
+}
+
+// GetEarlyAccessDownloadURL asks the plugin for the newest early-access
+// archive of a major version
+func (p *PluginDistributor) GetEarlyAccessDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return p.download(ctx, version, arch, imageType, true)
+}
+
+// versions asks the plugin for its GA or early-access versions
+func (p *PluginDistributor) versions(ctx context.Context, earlyAccess bool) ([]JavaRelease, error) {
+	resp, err := p.call(ctx, pluginRequest{Action: "list", EA: earlyAccess})
+	if err != nil {
+		return nil, err
+	}
+	for idx := range resp.Releases {
+		resp.Releases[idx].EA = earlyAccess
+	}
+	return groupReleases(resp.Releases), nil
+}
+
+// download asks the plugin for a GA or early-access archive
+func (p *PluginDistributor) download(ctx context.Context, version string, arch string, imageType string, earlyAccess bool) (*DownloadInfo, error) {
+	if imageType == "" {
+		imageType = ImageTypeJDK
+	}
+
+	resp, err := p.call(ctx, pluginRequest{
+		Action:    "download",
+		Version:   version,
+		Arch:      ArchLabel(arch),
+		ImageType: imageType,
+		OS:        "windows",
+		EA:        earlyAccess,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Package == nil || resp.Package.URL == "" {
+		return nil, fmt.Errorf("%s returned no package for Java %s on %s", p.name, version, ArchLabel(arch))
+	}
+
+	info, err := resp.Package.downloadInfo(ctx, arch, imageType)
+	if err != nil {
+		return nil, err
+	}
+	info.EarlyAccess = earlyAccess
+	return info, nil
+}
+
+// call runs the plugin once and decodes its response
+func (p *PluginDistributor) call(ctx context.Context, req pluginRequest) (*pluginResponse, error) {
+	req.Protocol = pluginProtocol
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(callCtx, p.command, p.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "JV_PLUGIN_PROTOCOL="+strconv.Itoa(pluginProtocol))
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	if errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s plugin timed out after %s", p.name, pluginTimeout)
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%s plugin failed: %w: %s", p.name, runErr, strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("%s plugin returned invalid JSON: %w", p.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s: %s", p.name, resp.Error)
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("%s plugin failed: %w: %s", p.name, runErr, strings.TrimSpace(stderr.String()))
+	}
+	return &resp, nil
+}
+
+// groupReleases turns the releases of an index or plugin into one entry per
+// major version, newest first. A major version is LTS or early access if any
+// of its releases is.
+func groupReleases(list []externalRelease) []JavaRelease {
+	byMajor := make(map[string]*JavaRelease)
+	var releases []*JavaRelease
+	for _, r := range list {
+		major := r.major()
+		if major == "" {
+			continue
+		}
+		entry, ok := byMajor[major]
+		if !ok {
+			entry = &JavaRelease{Version: major}
+			byMajor[major] = entry
+			releases = append(releases, entry)
+		}
+		entry.IsLTS = entry.IsLTS || r.LTS
+		entry.IsEA = entry.IsEA || r.EA
+		if CompareReleases(r.Release, entry.OpenJDKVersion) > 0 {
+			entry.OpenJDKVersion = r.Release
+			entry.Notes = r.notes()
+		}
+	}
+
+	result := make([]JavaRelease, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, *r)
+	}
+
+	// Sort descending by version
+	sort.Slice(result, func(i, j int) bool {
+		a, errA := strconv.Atoi(result[i].Version)
+		b, errB := strconv.Atoi(result[j].Version)
+		if errA != nil || errB != nil {
+			return result[i].Version > result[j].Version
+		}
+		return a > b
+	})
+	return result
+}
+
+// matches reports whether a package is built for arch and imageType
+func (p *externalPackage) matches(arch string, imageType string) bool {
+	pkgArch := ArchX64
+	if p.Arch != "" {
+		parsed, err := ParseArch(p.Arch)
+		if err != nil {
+			return false
+		}
+		pkgArch = parsed
+	}
+
+	pkgImage := p.ImageType
+	if pkgImage == "" {
+		pkgImage = ImageTypeJDK
+	}
+	return pkgArch == arch && strings.EqualFold(pkgImage, imageType)
+}
+
+// downloadInfo converts a package into download information, fetching its
+// checksum file when no digest is given
+func (p *externalPackage) downloadInfo(ctx context.Context, arch string, imageType string) (*DownloadInfo, error) {
+	fileName := p.FileName
+	if fileName == "" {
+		if filePath, ok := localSourcePath(p.URL); ok {
+			fileName = filepath.Base(filePath)
+		} else if u, err := url.Parse(p.URL); err == nil {
+			fileName = path.Base(u.Path)
+		}
+	}
+	fileName, err := packageFileName(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := strings.ToLower(strings.TrimSpace(p.Checksum))
+	if checksum == "" && p.ChecksumURL != "" {
+		digest, err := fetchChecksumFile(ctx, p.ChecksumURL, fileName, nil)
+		if err != nil {
+			return nil, err
+		}
+		checksum = digest
+	}
+	algo := ""
+	if checksum != "" {
+		algo = checksumAlgoForDigest(checksum)
+		if p.ChecksumAlgo != "" {
+			algo = normalizeChecksumAlgo(p.ChecksumAlgo)
+		}
+		if err := validateDigest(checksum, algo); err != nil {
+			return nil, fmt.Errorf("invalid checksum for %s: %w", fileName, err)
+		}
+	}
+
+	return &DownloadInfo{
+		URL:          p.URL,
+		Checksum:     checksum,
+		ChecksumAlgo: algo,
+		Size:         p.Size,
+		FileName:     fileName,
+		ImageType:    imageType,
+		Arch:         arch,
+		Release:      p.Release,
+		SignatureURL: p.SignatureURL,
+	}, nil
+}
+
+// packageFileName checks the archive name of a package. It comes from an
+// index or a plugin and becomes part of the download and cache paths, so it
+// must be a plain file name.
+func packageFileName(name string) (string, error) {
+	base := filepath.Base(name)
+	if name == "" || base != name || base == "." || base == ".." || strings.ContainsAny(base, `/\:`) {
+		return "", fmt.Errorf("invalid package file name %q", name)
+	}
+	return base, nil
+}
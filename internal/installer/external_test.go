@@ -0,0 +1,382 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"jv/internal/config"
+)
+
+func TestPackageFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkg     string
+		wantErr string // Substring of the expected error; empty for success
+	}{
+		{name: "zip", pkg: "OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip"},
+		{name: "tar.gz", pkg: "jdk-21.0.5.tar.gz"},
+		{name: "name with spaces", pkg: "Company JDK 21.zip"},
+		{name: "empty", pkg: "", wantErr: `invalid package file name ""`},
+		{name: "dot", pkg: ".", wantErr: "invalid package file name"},
+		{name: "parent", pkg: "..", wantErr: "invalid package file name"},
+		{name: "relative path", pkg: "../jdk.zip", wantErr: "invalid package file name"},
+		{name: "subdirectory", pkg: "dist/jdk.zip", wantErr: "invalid package file name"},
+		{name: "backslash", pkg: `dist\jdk.zip`, wantErr: "invalid package file name"},
+		{name: "absolute path", pkg: "/tmp/jdk.zip", wantErr: "invalid package file name"},
+		{name: "drive letter", pkg: "C:jdk.zip", wantErr: "invalid package file name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := packageFileName(tt.pkg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("packageFileName(%q) = %q, %v, want error containing %q", tt.pkg, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("packageFileName(%q): %v", tt.pkg, err)
+			}
+			if got != tt.pkg {
+				t.Errorf("packageFileName(%q) = %q, want %q", tt.pkg, got, tt.pkg)
+			}
+		})
+	}
+}
+
+func TestResolveIndexRef(t *testing.T) {
+	const remoteIndex = "https://jdks.example.com/builds/index.json"
+	localIndex := filepath.FromSlash("/srv/jdks/index.json")
+
+	tests := []struct {
+		name  string
+		index string
+		ref   string
+		want  string
+	}{
+		{name: "empty ref", index: remoteIndex, ref: "", want: ""},
+		{name: "absolute URL", index: remoteIndex, ref: "https://cdn.example.com/jdk.zip", want: "https://cdn.example.com/jdk.zip"},
+		{name: "relative to URL", index: remoteIndex, ref: "21/jdk.zip", want: "https://jdks.example.com/builds/21/jdk.zip"},
+		{name: "parent of URL", index: remoteIndex, ref: "../archive/jdk.zip", want: "https://jdks.example.com/archive/jdk.zip"},
+		{name: "rooted on URL host", index: remoteIndex, ref: "/dist/jdk.zip", want: "https://jdks.example.com/dist/jdk.zip"},
+		{name: "UNC ref", index: remoteIndex, ref: `\\fileserver\jdks\jdk.zip`, want: `\\fileserver\jdks\jdk.zip`},
+		{name: "relative to local index", index: localIndex, ref: "21/jdk.zip", want: filepath.FromSlash("/srv/jdks/21/jdk.zip")},
+		{name: "relative to file URL", index: "file:///srv/jdks/index.json", ref: "21/jdk.zip", want: filepath.FromSlash("/srv/jdks/21/jdk.zip")},
+		{name: "URL from local index", index: localIndex, ref: "https://cdn.example.com/jdk.zip", want: "https://cdn.example.com/jdk.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveIndexRef(tt.index, tt.ref); got != tt.want {
+				t.Errorf("resolveIndexRef(%q, %q) = %q, want %q", tt.index, tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+var (
+	sha1Digest   = strings.Repeat("1a", 20)
+	sha256Digest = strings.Repeat("2b", 32)
+	sha512Digest = strings.Repeat("5c", 64)
+)
+
+// testIndex is a release index with several releases of two major versions,
+// packages for different architectures and image types, and an
+// early-access build
+var testIndex = `releases:
+  - version: "21"
+    release: 21.0.4+7
+    lts: true
+    packages:
+      - url: 21/jdk-21.0.4.zip
+        checksum: ` + sha256Digest + `
+  - release: 21.0.5+11
+    date: 2024-10-16
+    notes_url: https://jdks.example.com/notes/21.0.5
+    cves: [cve-2024-21235, CVE-2024-21208, CVE-2024-21235]
+    packages:
+      - url: 21/jdk-21.0.5.zip
+        checksum: ` + sha512Digest + `
+      - url: 21/jre-21.0.5.zip
+        image_type: jre
+        checksum: ` + sha1Digest + `
+      - url: https://cdn.example.com/jdk-21.0.5-x86.zip
+        arch: x86
+        checksum: ` + sha256Digest + `
+        checksum_algo: SHA-256
+        file_name: jdk-21.0.5-x86.zip
+      - url: 21/jdk-21.0.5.1.zip
+        release: 21.0.5.1+1
+        arch: aarch64
+        checksum: ` + sha256Digest + `
+  - version: "17"
+    release: 17.0.13+11
+    lts: true
+    packages:
+      - url: 17/jdk-17.0.13.zip
+        checksum: ` + sha256Digest + `
+  - version: "24"
+    release: 24-ea+20
+    ea: true
+    packages:
+      - url: ea/jdk-24-ea.zip
+        checksum: ` + sha256Digest + `
+`
+
+// newTestIndex writes an index file and returns a distributor reading it
+func newTestIndex(t *testing.T, name string, content string) Distributor {
+	t.Helper()
+	location := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(location, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewExternalDistributor(config.ExternalDistributor{ID: "corp", Name: "Corp JDK", Index: location})
+	if err != nil {
+		t.Fatalf("NewExternalDistributor(): %v", err)
+	}
+	return d
+}
+
+// releaseList describes versions like "21 LTS 21.0.5+11"
+func releaseList(releases []JavaRelease) string {
+	var entries []string
+	for _, r := range releases {
+		entry := r.Version
+		if r.IsLTS {
+			entry += " LTS"
+		}
+		if r.IsEA {
+			entry += " EA"
+		}
+		entries = append(entries, entry+" "+r.OpenJDKVersion)
+	}
+	return strings.Join(entries, ", ")
+}
+
+func TestIndexDistributorVersions(t *testing.T) {
+	jsonIndex := `{"releases": [
+		{"version": "17", "release": "17.0.13+11", "lts": true},
+		{"release": "21.0.5+11", "lts": true},
+		{"release": "21.0.4+7"},
+		{"release": "23.0.1+11"}
+	]}`
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "YAML", file: "index.yaml", content: testIndex, want: "21 LTS 21.0.5+11, 17 LTS 17.0.13+11"},
+		{name: "JSON", file: "index.json", content: jsonIndex, want: "23 23.0.1+11, 21 LTS 21.0.5+11, 17 LTS 17.0.13+11"},
+		{name: "JSON without extension", file: "index", content: jsonIndex, want: "23 23.0.1+11, 21 LTS 21.0.5+11, 17 LTS 17.0.13+11"},
+		{name: "no releases", file: "index.yaml", content: "releases: []\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestIndex(t, tt.file, tt.content)
+			releases, err := d.GetAvailableVersions(context.Background())
+			if err != nil {
+				t.Fatalf("GetAvailableVersions(): %v", err)
+			}
+			if got := releaseList(releases); got != tt.want {
+				t.Errorf("GetAvailableVersions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexDistributorVersionNotes(t *testing.T) {
+	d := newTestIndex(t, "index.yaml", testIndex)
+	releases, err := d.GetAvailableVersions(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailableVersions(): %v", err)
+	}
+
+	notes := releases[0].Notes
+	if notes == nil {
+		t.Fatal("Java 21 has no release notes, want those of 21.0.5+11")
+	}
+	if !notes.Date.Equal(time.Date(2024, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Date = %v, want 2024-10-16", notes.Date)
+	}
+	if got := strings.Join(notes.CVEs, ","); got != "CVE-2024-21208,CVE-2024-21235" {
+		t.Errorf("CVEs = %s, want CVE-2024-21208,CVE-2024-21235", got)
+	}
+	if releases[1].Notes != nil {
+		t.Errorf("Java 17 notes = %+v, want none", releases[1].Notes)
+	}
+}
+
+func TestIndexDistributorDownloadURL(t *testing.T) {
+	d := newTestIndex(t, "index.yaml", testIndex)
+	indexDir := filepath.Dir(d.(*IndexDistributor).location)
+
+	tests := []struct {
+		name         string
+		version      string
+		arch         string
+		imageType    string
+		wantURL      string
+		wantRelease  string
+		wantFileName string
+		wantAlgo     string
+		wantErr      string
+	}{
+		{
+			name: "newest release", version: "21", arch: ArchX64, imageType: ImageTypeJDK,
+			wantURL: filepath.Join(indexDir, "21", "jdk-21.0.5.zip"), wantRelease: "21.0.5+11", wantFileName: "jdk-21.0.5.zip", wantAlgo: ChecksumSHA512,
+		},
+		{
+			name: "default image type", version: "17", arch: ArchX64,
+			wantURL: filepath.Join(indexDir, "17", "jdk-17.0.13.zip"), wantRelease: "17.0.13+11", wantFileName: "jdk-17.0.13.zip", wantAlgo: ChecksumSHA256,
+		},
+		{
+			name: "image type", version: "21", arch: ArchX64, imageType: ImageTypeJRE,
+			wantURL: filepath.Join(indexDir, "21", "jre-21.0.5.zip"), wantRelease: "21.0.5+11", wantFileName: "jre-21.0.5.zip", wantAlgo: ChecksumSHA1,
+		},
+		{
+			name: "architecture and file name", version: "21", arch: ArchX86, imageType: ImageTypeJDK,
+			wantURL: "https://cdn.example.com/jdk-21.0.5-x86.zip", wantRelease: "21.0.5+11", wantFileName: "jdk-21.0.5-x86.zip", wantAlgo: ChecksumSHA256,
+		},
+		{
+			name: "package release", version: "21", arch: ArchAArch64, imageType: ImageTypeJDK,
+			wantURL: filepath.Join(indexDir, "21", "jdk-21.0.5.1.zip"), wantRelease: "21.0.5.1+1", wantFileName: "jdk-21.0.5.1.zip", wantAlgo: ChecksumSHA256,
+		},
+		{name: "no such image type", version: "17", arch: ArchX64, imageType: ImageTypeJRE, wantErr: "no JRE found for Java 17"},
+		{name: "no such version", version: "11", arch: ArchX64, imageType: ImageTypeJDK, wantErr: "no JDK found for Java 11"},
+		// Early-access builds are only offered with --ea
+		{name: "early access skipped", version: "24", arch: ArchX64, imageType: ImageTypeJDK, wantErr: "no JDK found for Java 24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := d.GetDownloadURL(context.Background(), tt.version, tt.arch, tt.imageType)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetDownloadURL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDownloadURL(): %v", err)
+			}
+			if info.URL != tt.wantURL || info.Release != tt.wantRelease || info.FileName != tt.wantFileName {
+				t.Errorf("GetDownloadURL() = %s (%s, %s), want %s (%s, %s)", info.URL, info.Release, info.FileName, tt.wantURL, tt.wantRelease, tt.wantFileName)
+			}
+			if info.ChecksumAlgo != tt.wantAlgo || info.Checksum == "" {
+				t.Errorf("checksum = %s %q, want a %s digest", info.ChecksumAlgo, info.Checksum, tt.wantAlgo)
+			}
+			if info.Arch != tt.arch || info.EarlyAccess {
+				t.Errorf("Arch = %s, EarlyAccess = %v; want %s and a GA release", info.Arch, info.EarlyAccess, tt.arch)
+			}
+		})
+	}
+}
+
+func TestIndexDistributorInvalidIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "broken JSON", content: `{"releases": [`, wantErr: "failed to parse release index"},
+		{name: "broken YAML", content: "releases:\n  - version: [21\n", wantErr: "failed to parse release index"},
+		{
+			name:    "checksum of the wrong length",
+			content: "releases:\n  - release: 21.0.5+11\n    packages:\n      - url: jdk.zip\n        checksum: " + sha256Digest + "\n        checksum_algo: sha512\n",
+			wantErr: "invalid checksum for jdk.zip",
+		},
+		{
+			name:    "file name with a path",
+			content: "releases:\n  - release: 21.0.5+11\n    packages:\n      - url: jdk.zip\n        file_name: ../jdk.zip\n",
+			wantErr: "invalid package file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestIndex(t, "index.yaml", tt.content)
+			_, err := d.GetDownloadURL(context.Background(), "21", ArchX64, ImageTypeJDK)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GetDownloadURL() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	d, _ := NewExternalDistributor(config.ExternalDistributor{ID: "corp", Index: missing})
+	if _, err := d.GetAvailableVersions(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to read release index") {
+		t.Errorf("GetAvailableVersions() of a missing index error = %v, want a read error", err)
+	}
+}
+
+func TestNewExternalDistributor(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings config.ExternalDistributor
+		wantErr  string
+		wantName string
+	}{
+		{name: "index", settings: config.ExternalDistributor{ID: "Corp", Index: "https://jdks.example.com/index.json"}, wantName: "Corp"},
+		{name: "plugin", settings: config.ExternalDistributor{ID: "corp", Name: "Corp JDK", Command: "corp-jdks.exe"}, wantName: "Corp JDK"},
+		{name: "no id", settings: config.ExternalDistributor{Index: "index.json"}, wantErr: "without an id"},
+		{name: "built-in id", settings: config.ExternalDistributor{ID: "Adoptium", Index: "index.json"}, wantErr: "reserved"},
+		{name: "custom id", settings: config.ExternalDistributor{ID: "custom", Index: "index.json"}, wantErr: "reserved"},
+		{name: "id with a path", settings: config.ExternalDistributor{ID: "../corp", Index: "index.json"}, wantErr: "external distributor id"},
+		{name: "name with a path", settings: config.ExternalDistributor{ID: "corp", Name: `Corp\JDK`, Index: "index.json"}, wantErr: "invalid name"},
+		{name: "index and command", settings: config.ExternalDistributor{ID: "corp", Index: "index.json", Command: "corp.exe"}, wantErr: "both index and command"},
+		{name: "neither", settings: config.ExternalDistributor{ID: "corp"}, wantErr: "needs an index or a command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewExternalDistributor(tt.settings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewExternalDistributor() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewExternalDistributor(): %v", err)
+			}
+			if d.Name() != tt.wantName || d.ID() != strings.ToLower(tt.settings.ID) {
+				t.Errorf("distributor %s (%s), want %s (%s)", d.Name(), d.ID(), tt.wantName, strings.ToLower(tt.settings.ID))
+			}
+		})
+	}
+}
+
+func TestIndexDistributorEarlyAccess(t *testing.T) {
+	d := newTestIndex(t, "index.yaml", testIndex)
+	ea := d.(EarlyAccessDistributor)
+
+	releases, err := ea.GetEarlyAccessVersions(context.Background())
+	if err != nil {
+		t.Fatalf("GetEarlyAccessVersions(): %v", err)
+	}
+	if got := releaseList(releases); got != "24 EA 24-ea+20" {
+		t.Errorf("GetEarlyAccessVersions() = %q, want only 24 EA 24-ea+20", got)
+	}
+
+	info, err := ea.GetEarlyAccessDownloadURL(context.Background(), "24", ArchX64, ImageTypeJDK)
+	if err != nil {
+		t.Fatalf("GetEarlyAccessDownloadURL(): %v", err)
+	}
+	if !info.EarlyAccess || info.Release != "24-ea+20" {
+		t.Errorf("GetEarlyAccessDownloadURL() = %s (early access %v), want 24-ea+20 as early access", info.Release, info.EarlyAccess)
+	}
+
+	// GA releases are not early-access builds
+	_, err = ea.GetEarlyAccessDownloadURL(context.Background(), "21", ArchX64, ImageTypeJDK)
+	if err == nil || !strings.Contains(err.Error(), "no early-access JDK found for Java 21") {
+		t.Errorf("GetEarlyAccessDownloadURL() of a GA version error = %v, want none found", err)
+	}
+}
@@ -0,0 +1,530 @@
+package installer
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxExtractSize caps the total uncompressed size of an archive. The largest
+// debug images are around 1 GB, so anything past this is corrupt or hostile.
+var maxExtractSize int64 = 4 << 30
+
+// errExtractTooLarge is returned when an archive exceeds maxExtractSize
+var errExtractTooLarge = fmt.Errorf("archive exceeds the %s uncompressed size limit", FormatSize(maxExtractSize))
+
+// pendingLink is a link entry whose creation is deferred until all regular
+// files are on disk, so links cannot be used to redirect later writes and a
+// copy can stand in when symlinks are not permitted
+type pendingLink struct {
+	name   string // Entry name of the link, relative to destDir
+	target string // Link target as stored in the archive
+	hard   bool   // Hard link targets are entry names rather than relative paths
+}
+
+// extraction tracks the state shared by the zip and tar extractors
+type extraction struct {
+	destDir   string
+	remaining int64
+	names     []string
+	links     []pendingLink
+	symlinks  map[string]bool // Cleaned names of the symlink entries, set by finish
+}
+
+func newExtraction(destDir string) (*extraction, error) {
+	if err := os.RemoveAll(destDir); err != nil {
+		return nil, fmt.Errorf("failed to clean extraction directory: %w", err)
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+	return &extraction{destDir: destDir, remaining: maxExtractSize}, nil
+}
+
+// dir creates a directory entry
+func (x *extraction) dir(name string) error {
+	dirPath, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return nil
+}
+
+// file writes a regular file entry, enforcing the size cap on the bytes
+// actually decompressed since declared sizes may lie
+func (x *extraction) file(name string, mode os.FileMode, r io.Reader) error {
+	filePath, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	outFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode(mode))
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer outFile.Close()
+
+	written, err := io.Copy(outFile, io.LimitReader(r, x.remaining+1))
+	if err != nil {
+		return fmt.Errorf("failed to extract file: %w", err)
+	}
+	if written > x.remaining {
+		return errExtractTooLarge
+	}
+	x.remaining -= written
+
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("failed to extract file: %w", err)
+	}
+	return nil
+}
+
+// link records a symlink or hard link entry for creation by finish
+func (x *extraction) link(name string, target string, hard bool) error {
+	if _, err := x.path(name); err != nil {
+		return err
+	}
+	x.links = append(x.links, pendingLink{name: name, target: target, hard: hard})
+	return nil
+}
+
+// path validates an entry name and resolves it inside destDir
+func (x *extraction) path(name string) (string, error) {
+	p, err := safeJoin(x.destDir, name)
+	if err != nil {
+		return "", err
+	}
+	x.names = append(x.names, name)
+	return p, nil
+}
+
+// finish checks the archive layout, creates deferred links and returns the
+// archive's top-level directory
+func (x *extraction) finish() (string, error) {
+	rootDir, err := archiveRoot(x.names)
+	if err != nil {
+		return "", err
+	}
+
+	x.symlinks = make(map[string]bool)
+	for _, link := range x.links {
+		if name, err := cleanEntryName(link.name); err == nil && !link.hard {
+			x.symlinks[name] = true
+		}
+	}
+	for _, name := range x.names {
+		if err := x.checkParents(name); err != nil {
+			return "", err
+		}
+	}
+	for _, link := range x.links {
+		if err := x.createLink(rootDir, link); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(x.destDir, rootDir), nil
+}
+
+// createLink creates one deferred link. Creating symlinks on Windows needs
+// Developer Mode or administrator rights, so a copy of the target is made
+// when the link itself cannot be created.
+func (x *extraction) createLink(rootDir string, link pendingLink) error {
+	linkPath, err := safeJoin(x.destDir, link.name)
+	if err != nil {
+		return err
+	}
+
+	var source string
+	if link.hard {
+		if err := x.checkHardLinkTarget(rootDir, link); err != nil {
+			return err
+		}
+		source, _ = safeJoin(x.destDir, link.target)
+	} else {
+		if err := x.checkLinkTarget(rootDir, link); err != nil {
+			return err
+		}
+		source = filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(strings.ReplaceAll(link.target, "\\", "/")))
+	}
+	// Follow what is on disk as well, in case links created so far lead
+	// somewhere the checks above did not foresee
+	if err := x.checkOnDisk(rootDir, link.name, source); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	os.Remove(linkPath)
+
+	if link.hard {
+		if err := os.Link(source, linkPath); err == nil {
+			return nil
+		}
+	} else {
+		target := filepath.FromSlash(strings.ReplaceAll(link.target, "\\", "/"))
+		if err := os.Symlink(target, linkPath); err == nil {
+			return nil
+		}
+	}
+
+	if err := copyTree(source, linkPath); err != nil {
+		return fmt.Errorf("failed to create link %s: %w", link.name, err)
+	}
+	return nil
+}
+
+// ExtractArchive extracts a JDK archive into destDir, which is emptied first,
+// and returns the JDK root directory. The format is chosen from fileName and
+// falls back to the archive's leading bytes. Entries with absolute names or
+// ".." components are rejected, symlinks may only point inside the archive's
+// root and the total uncompressed size is capped at maxExtractSize.
+// Extraction stops when ctx is cancelled; the caller removes destDir.
+func ExtractArchive(ctx context.Context, archivePath string, destDir string, fileName string) (string, error) {
+	format, err := archiveFormat(archivePath, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	var rootDir string
+	switch format {
+	case formatZip:
+		rootDir, err = ExtractZip(ctx, archivePath, destDir)
+	case formatTarGz:
+		rootDir, err = ExtractTarGz(ctx, archivePath, destDir)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return jdkHome(rootDir), nil
+}
+
+// jdkHome returns the directory holding bin/ for an extracted archive root.
+// macOS packages wrap the JDK in a jdk-x.jdk/Contents/Home bundle.
+func jdkHome(rootDir string) string {
+	home := filepath.Join(rootDir, "Contents", "Home")
+	if info, err := os.Stat(filepath.Join(home, "bin")); err == nil && info.IsDir() {
+		return home
+	}
+	return rootDir
+}
+
+// ExtractZip extracts a ZIP file into destDir, which is emptied first, and
+// returns the path of the archive's single top-level directory
+func ExtractZip(ctx context.Context, zipPath string, destDir string) (string, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	var declared uint64
+	for _, file := range reader.File {
+		declared += file.UncompressedSize64
+	}
+	if declared > uint64(maxExtractSize) {
+		return "", errExtractTooLarge
+	}
+
+	x, err := newExtraction(destDir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range reader.File {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.dir(file.Name)
+
+		case mode&os.ModeSymlink != 0:
+			var target string
+			target, err = readZipEntry(file, 4096)
+			if err != nil {
+				return "", fmt.Errorf("failed to read symlink %s: %w", file.Name, err)
+			}
+			err = x.link(file.Name, target, false)
+
+		case mode.IsRegular():
+			var rc io.ReadCloser
+			rc, err = file.Open()
+			if err != nil {
+				return "", fmt.Errorf("failed to open file in zip: %w", err)
+			}
+			err = x.file(file.Name, mode, contextReader{ctx: ctx, r: rc})
+			rc.Close()
+
+		default:
+			err = fmt.Errorf("unsupported entry %s in archive (mode %s)", file.Name, mode)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return x.finish()
+}
+
+// readZipEntry returns the contents of a small entry such as a symlink target
+func readZipEntry(file *zip.File, limit int64) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > limit {
+		return "", fmt.Errorf("target longer than %d bytes", limit)
+	}
+	return string(data), nil
+}
+
+// fileMode keeps only the executable bit of an archived mode, so archives
+// cannot create setuid, world-writable or otherwise unusual files
+func fileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// archiveRoot returns the single top-level directory shared by every entry
+func archiveRoot(names []string) (string, error) {
+	var root string
+	nested := false
+
+	for _, name := range names {
+		clean, err := cleanEntryName(name)
+		if err != nil {
+			return "", err
+		}
+		if clean == "" {
+			continue
+		}
+
+		first, rest, hasRest := strings.Cut(clean, "/")
+		// macOS archivers add resource fork folders next to the real root
+		if first == "__MACOSX" {
+			continue
+		}
+		if root != "" && first != root {
+			return "", fmt.Errorf("archive has more than one top-level entry (%s, %s)", root, first)
+		}
+		root = first
+		if hasRest && rest != "" || strings.HasSuffix(name, "/") {
+			nested = true
+		}
+	}
+
+	if root == "" || !nested {
+		return "", fmt.Errorf("archive does not contain a top-level directory")
+	}
+	return root, nil
+}
+
+// cleanEntryName normalises an archive entry name to a relative, slash
+// separated path, rejecting absolute names and ".." components
+func cleanEntryName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		(len(slashed) >= 2 && slashed[1] == ':') {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("archive entry %q escapes the extraction directory", name)
+		}
+	}
+
+	clean := path.Clean(slashed)
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+// safeJoin resolves an archive entry name inside destDir
+func safeJoin(destDir string, name string) (string, error) {
+	clean, err := cleanEntryName(name)
+	if err != nil {
+		return "", err
+	}
+	if clean == "" {
+		return destDir, nil
+	}
+	return filepath.Join(destDir, filepath.FromSlash(clean)), nil
+}
+
+// checkParents rejects entries below a symlink from the archive, which could
+// redirect them anywhere once the link exists
+func (x *extraction) checkParents(name string) error {
+	clean, err := cleanEntryName(name)
+	if err != nil {
+		return err
+	}
+	for dir := path.Dir(clean); dir != "."; dir = path.Dir(dir) {
+		if x.symlinks[dir] {
+			return fmt.Errorf("archive entry %q passes through the symlink %s", name, dir)
+		}
+	}
+	return nil
+}
+
+// checkLinkTarget rejects symlinks that are absolute, resolve outside the
+// archive's root directory or pass through another symlink from the archive
+// on the way, since a path through a link cannot be resolved as text
+func (x *extraction) checkLinkTarget(rootDir string, link pendingLink) error {
+	slashed := strings.ReplaceAll(link.target, "\\", "/")
+	if link.target == "" || strings.HasPrefix(slashed, "/") || filepath.IsAbs(link.target) ||
+		filepath.VolumeName(link.target) != "" || (len(slashed) >= 2 && slashed[1] == ':') {
+		return fmt.Errorf("symlink %s has an absolute or empty target %q", link.name, link.target)
+	}
+
+	name, err := cleanEntryName(link.name)
+	if err != nil {
+		return err
+	}
+
+	// Walk the target one component at a time from the link's directory
+	var current []string
+	if dir := path.Dir(name); dir != "." {
+		current = strings.Split(dir, "/")
+	}
+	parts := strings.Split(slashed, "/")
+	for idx, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(current) == 0 {
+				return fmt.Errorf("symlink %s points outside the archive (%s)", link.name, link.target)
+			}
+			current = current[:len(current)-1]
+		default:
+			current = append(current, part)
+		}
+		if idx < len(parts)-1 && x.symlinks[strings.Join(current, "/")] {
+			return fmt.Errorf("symlink %s points through the symlink %s (%s)", link.name, strings.Join(current, "/"), link.target)
+		}
+	}
+
+	if len(current) == 0 || current[0] != rootDir {
+		return fmt.Errorf("symlink %s points outside the archive (%s)", link.name, link.target)
+	}
+	return nil
+}
+
+// checkHardLinkTarget rejects hard links to entries outside the archive's
+// root directory, below a symlink, or to a symlink, which would be linked
+// itself and resolve relative to its new location
+func (x *extraction) checkHardLinkTarget(rootDir string, link pendingLink) error {
+	target, err := cleanEntryName(link.target)
+	if err != nil {
+		return fmt.Errorf("hard link %s: %w", link.name, err)
+	}
+	if first, _, _ := strings.Cut(target, "/"); target == "" || first != rootDir {
+		return fmt.Errorf("hard link %s points outside the archive (%s)", link.name, link.target)
+	}
+	if x.symlinks[target] {
+		return fmt.Errorf("hard link %s points to the symlink %s", link.name, link.target)
+	}
+	if err := x.checkParents(link.target); err != nil {
+		return fmt.Errorf("hard link %s: %w", link.name, err)
+	}
+	return nil
+}
+
+// checkOnDisk resolves the source of a link through the links already on
+// disk and rejects it unless it stays inside the archive's root directory.
+// A source that does not exist yet is left to the link itself.
+func (x *extraction) checkOnDisk(rootDir string, name string, source string) error {
+	resolved, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(filepath.Join(x.destDir, rootDir))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", rootDir, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("link %s resolves outside the archive", name)
+	}
+	return nil
+}
+
+// copyTree copies a file or directory, used in place of a symlink
+func copyTree(src string, dst string) error {
+	info, err := os.Stat(src)
+	if errors.Is(err, os.ErrNotExist) {
+		// Dangling links carry nothing worth copying
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst, fileMode(info.Mode()))
+	}
+
+	return filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return copyFile(p, target, fileMode(fi.Mode()))
+	})
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
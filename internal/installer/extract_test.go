@@ -0,0 +1,363 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Entry kinds of a crafted test archive
+const (
+	entryDir = iota
+	entryFile
+	entrySymlink
+	entryHardLink
+)
+
+// testEntry is one entry of a crafted archive. body is the file content or
+// the link target.
+type testEntry struct {
+	kind int
+	name string
+	body string
+}
+
+func testDir(name string) testEntry               { return testEntry{entryDir, name, ""} }
+func testFile(name string, body string) testEntry { return testEntry{entryFile, name, body} }
+func testSymlink(name, target string) testEntry   { return testEntry{entrySymlink, name, target} }
+func testHardLink(name, target string) testEntry  { return testEntry{entryHardLink, name, target} }
+
+// buildZip writes entries as a ZIP archive and returns its path
+func buildZip(t *testing.T, entries []testEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		switch e.kind {
+		case entryDir:
+			hdr.SetMode(os.ModeDir | 0755)
+		case entryFile:
+			hdr.SetMode(0644)
+		case entrySymlink:
+			hdr.SetMode(os.ModeSymlink | 0777)
+		case entryHardLink:
+			t.Fatalf("ZIP archives have no hard links")
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.kind != entryDir {
+			if _, err := w.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeArchive(t, "test.zip", buf.Bytes())
+}
+
+// buildTarGz writes entries as a gzip-compressed tarball and returns its path
+func buildTarGz(t *testing.T, entries []testEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644}
+		switch e.kind {
+		case entryDir:
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		case entryFile:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, int64(len(e.body))
+		case entrySymlink:
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.body
+		case entryHardLink:
+			hdr.Typeflag, hdr.Linkname = tar.TypeLink, e.body
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if e.kind == entryFile {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return writeArchive(t, "test.tar.gz", buf.Bytes())
+}
+
+func writeArchive(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+// extractInto extracts an archive into a fresh "extract" directory and fails
+// the test if anything was written next to it
+func extractInto(t *testing.T, archivePath string) (string, error) {
+	t.Helper()
+	parent := t.TempDir()
+	root, err := ExtractArchive(context.Background(), archivePath, filepath.Join(parent, "extract"), filepath.Base(archivePath))
+
+	entries, readErr := os.ReadDir(parent)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "extract" {
+			t.Errorf("extraction wrote %s outside the extraction directory", entry.Name())
+		}
+	}
+	return root, err
+}
+
+func TestExtractArchiveEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		tarOnly bool   // Uses hard links, which ZIP cannot store
+		wantErr string // Substring of the expected error; empty for success
+	}{
+		{
+			name: "valid JDK layout",
+			entries: []testEntry{
+				testDir("jdk/"), testDir("jdk/bin/"), testFile("jdk/bin/java", "java"),
+				testFile("jdk/lib/modules", "modules"), testSymlink("jdk/lib/link", "modules"),
+			},
+		},
+		{
+			name:    "parent directory component",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/../evil", "x")},
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "absolute name",
+			entries: []testEntry{testDir("jdk/"), testFile("/tmp/evil", "x")},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "drive letter name",
+			entries: []testEntry{testDir("jdk/"), testFile("C:/evil", "x")},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "drive-relative backslash name",
+			entries: []testEntry{testDir("jdk/"), testFile(`C:\evil`, "x")},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "symlink escaping through ..",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testSymlink("jdk/l", "../../outside")},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "symlink with absolute target",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testSymlink("jdk/l", "/etc/passwd")},
+			wantErr: "absolute or empty target",
+		},
+		{
+			name:    "symlink with drive letter target",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testSymlink("jdk/l", `C:\Windows`)},
+			wantErr: "absolute or empty target",
+		},
+		{
+			name: "symlink below a symlink",
+			entries: []testEntry{
+				testDir("jdk/"), testDir("jdk/a/b/"),
+				testSymlink("jdk/a/b/l1", "../.."), testSymlink("jdk/a/b/l1/l2", "../../../X"),
+			},
+			wantErr: "passes through the symlink",
+		},
+		{
+			name: "symlink target through a symlink",
+			entries: []testEntry{
+				testDir("jdk/"), testDir("jdk/a/b/"),
+				testSymlink("jdk/a/b/s", "../.."), testSymlink("jdk/a/b/e", "s/../../../X"),
+			},
+			wantErr: "points through the symlink",
+		},
+		{
+			name:    "file below a symlink",
+			entries: []testEntry{testDir("jdk/"), testSymlink("jdk/l", "."), testFile("jdk/l/evil", "x")},
+			wantErr: "passes through the symlink",
+		},
+		{
+			name:    "chained symlinks inside the root",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testSymlink("jdk/l1", "l2"), testSymlink("jdk/l2", "a")},
+		},
+		{
+			name:    "hard link inside the root",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testHardLink("jdk/h", "jdk/a")},
+			tarOnly: true,
+		},
+		{
+			name:    "hard link with .. target",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testHardLink("jdk/h", "../outside")},
+			tarOnly: true,
+			wantErr: "escapes the extraction directory",
+		},
+		{
+			name:    "hard link with absolute target",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testHardLink("jdk/h", "/etc/passwd")},
+			tarOnly: true,
+			wantErr: "absolute path",
+		},
+		{
+			name:    "hard link outside the root",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testHardLink("jdk/h", "other/a")},
+			tarOnly: true,
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "hard link to a symlink",
+			entries: []testEntry{testDir("jdk/"), testDir("jdk/a/"), testSymlink("jdk/s", "a"), testHardLink("jdk/a/h", "jdk/s")},
+			tarOnly: true,
+			wantErr: "points to the symlink",
+		},
+		{
+			name:    "hard link through a symlink",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a/f", "x"), testSymlink("jdk/s", "a"), testHardLink("jdk/h", "jdk/s/f")},
+			tarOnly: true,
+			wantErr: "passes through the symlink",
+		},
+		{
+			name:    "several top-level directories",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testDir("other/"), testFile("other/b", "y")},
+			wantErr: "more than one top-level entry",
+		},
+		{
+			name:    "no top-level directory",
+			entries: []testEntry{testFile("java.exe", "x")},
+			wantErr: "does not contain a top-level directory",
+		},
+		{
+			name:    "macOS resource forks next to the root",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", "x"), testFile("__MACOSX/._jdk", "fork")},
+		},
+	}
+
+	for _, tt := range tests {
+		builders := map[string]func(*testing.T, []testEntry) string{"tar.gz": buildTarGz}
+		if !tt.tarOnly {
+			builders["zip"] = buildZip
+		}
+		for format, build := range builders {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				root, err := extractInto(t, build(t, tt.entries))
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if filepath.Base(root) != "jdk" {
+						t.Errorf("root = %s, want the jdk directory", root)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveSizeLimit(t *testing.T) {
+	defer func(limit int64) { maxExtractSize = limit }(maxExtractSize)
+	maxExtractSize = 1024
+
+	tests := []struct {
+		name    string
+		entries []testEntry
+		wantErr bool
+	}{
+		{
+			name:    "within the limit",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", strings.Repeat("a", 600)), testFile("jdk/b", strings.Repeat("b", 400))},
+		},
+		{
+			name:    "single file over the limit",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", strings.Repeat("a", 2048))},
+			wantErr: true,
+		},
+		{
+			name:    "files adding up to more than the limit",
+			entries: []testEntry{testDir("jdk/"), testFile("jdk/a", strings.Repeat("a", 600)), testFile("jdk/b", strings.Repeat("b", 600))},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		for format, build := range map[string]func(*testing.T, []testEntry) string{"zip": buildZip, "tar.gz": buildTarGz} {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				_, err := extractInto(t, build(t, tt.entries))
+				if tt.wantErr != errors.Is(err, errExtractTooLarge) {
+					t.Fatalf("error = %v, want size limit error: %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractArchiveContentsHome(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		want    string // JDK home relative to the extraction directory
+	}{
+		{
+			name:    "plain JDK",
+			entries: []testEntry{testDir("jdk-21/"), testFile("jdk-21/bin/java", "java")},
+			want:    "jdk-21",
+		},
+		{
+			name: "macOS bundle",
+			entries: []testEntry{
+				testDir("jdk-21.jdk/"), testFile("jdk-21.jdk/Contents/Info.plist", "plist"),
+				testFile("jdk-21.jdk/Contents/Home/bin/java", "java"),
+			},
+			want: filepath.Join("jdk-21.jdk", "Contents", "Home"),
+		},
+		{
+			name:    "Contents folder without a JDK",
+			entries: []testEntry{testDir("app/"), testFile("app/Contents/readme", "x"), testFile("app/bin/java", "java")},
+			want:    "app",
+		},
+	}
+
+	for _, tt := range tests {
+		for format, build := range map[string]func(*testing.T, []testEntry) string{"zip": buildZip, "tar.gz": buildTarGz} {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				root, err := extractInto(t, build(t, tt.entries))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasSuffix(root, string(filepath.Separator)+tt.want) {
+					t.Errorf("root = %s, want it to end in %s", root, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package installer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"runtime"
+	"path/filepath"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"jv/internal/config"
 	"jv/internal/env"
+	"jv/internal/hooks"
 	"jv/internal/java"
 	"jv/internal/theme"
 
@@ -16,36 +20,82 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Options controls optional installer behaviour
+type Options struct {
+	Offline     bool   // Fall back to the archive cache when the distributor API is unreachable
+	Arch        string // Architecture to install for, e.g. ArchX86; empty means this machine's
+	EarlyAccess bool   // List and install early-access builds instead of GA releases
+
+	// Install archives that come without a checksum instead of refusing them
+	InsecureSkipChecksum bool
+}
+
 // Installer handles the interactive Java installation process
 type Installer struct {
 	detector     *java.Detector
 	config       *config.Config
 	isAdmin      bool
+	options      Options
 	distributors map[int]Distributor
 }
 
 // NewInstaller creates a new Installer instance
-func NewInstaller(isAdmin bool) (*Installer, error) {
+func NewInstaller(isAdmin bool, options Options) (*Installer, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	distributors := make(map[int]Distributor)
-	distributors[1] = NewAdoptiumDistributor()
+	distributors[1] = NewAdoptiumDistributor(cfg.Distributor(AdoptiumID))
 	// Future: distributors[2] = NewAzulDistributor()
 	// Future: distributors[3] = NewCorrettoDistributor()
 
-	return &Installer{
+	// Distributors defined by a release index or plugin follow the built-in ones
+	for _, settings := range cfg.ExternalDistributors {
+		d, err := NewExternalDistributor(settings)
+		if err != nil {
+			fmt.Println(theme.WarningMessage(fmt.Sprintf("Ignoring external distributor: %v", err)))
+			continue
+		}
+		distributors[len(distributors)+1] = d
+	}
+
+	i := &Installer{
 		detector:     java.NewDetector(),
 		config:       cfg,
 		isAdmin:      isAdmin,
+		options:      options,
 		distributors: distributors,
-	}, nil
+	}
+
+	// Clear out anything a crashed or interrupted run left behind
+	CleanupStale(i.installBases())
+
+	return i, nil
+}
+
+// installBases returns every directory installations can be placed in
+func (i *Installer) installBases() []string {
+	var bases []string
+	if base, err := installBaseDir("", false); err == nil {
+		bases = append(bases, base)
+	}
+	names := []string{CustomDistributor, OpenJFXVendor}
+	for _, d := range i.distributors {
+		names = append(names, d.Name())
+	}
+	for _, name := range names {
+		if base, err := installBaseDir(name, true); err == nil {
+			bases = append(bases, base)
+		}
+	}
+	return bases
 }
 
-// Run starts the interactive installation process
-func (i *Installer) Run() error {
+// Run starts the interactive installation process. Cancelling ctx stops a
+// running download or installation and cleans up after it.
+func (i *Installer) Run(ctx context.Context) error {
 	// Styled header with JV theme
 	title := theme.Title.Padding(0, 2).Render("Java Installation Manager")
 	fmt.Println()
@@ -72,41 +122,47 @@ func (i *Installer) Run() error {
 	}
 
 	if mode == "multi" {
-		return i.RunMultiInstall(distributor)
+		return i.RunMultiInstall(ctx, distributor)
 	}
 
 	// Single install (existing flow)
-	return i.RunSingleInstall(distributor)
+	return i.RunSingleInstall(ctx, distributor)
 }
 
 // RunSingleInstall handles single version installation
-func (i *Installer) RunSingleInstall(distributor Distributor) error {
+func (i *Installer) RunSingleInstall(ctx context.Context, distributor Distributor) error {
 	// Step 2: Select version
-	version, err := i.ShowVersionMenu(distributor)
+	version, err := i.ShowVersionMenu(ctx, distributor)
 	if err != nil {
 		return err
 	}
 
-	// Step 3: Select scope
+	// Step 3: Select image type
+	imageType, err := i.SelectImageType()
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Select scope
 	scope, err := i.SelectInstallScope()
 	if err != nil {
 		return err
 	}
 
-	// Step 4: Install
-	installedPath, err := i.InstallVersion(distributor, version, scope)
+	// Step 5: Install
+	installed, err := i.InstallVersion(ctx, distributor, version, imageType, scope)
 	if err != nil {
 		return err
 	}
 
-	// Step 5: Configure and save
-	return i.finalizeInstallation([]string{installedPath}, []string{version}, scope, distributor.Name())
+	// Step 6: Configure and save
+	return i.finalizeInstallation(ctx, []config.InstalledJDK{*installed})
 }
 
 // RunMultiInstall handles multiple versions installation
-func (i *Installer) RunMultiInstall(distributor Distributor) error {
+func (i *Installer) RunMultiInstall(ctx context.Context, distributor Distributor) error {
 	// Step 2: Select multiple versions
-	versions, err := i.SelectMultipleVersions(distributor)
+	versions, err := i.SelectMultipleVersions(ctx, distributor)
 	if err != nil {
 		return err
 	}
@@ -115,51 +171,155 @@ func (i *Installer) RunMultiInstall(distributor Distributor) error {
 		return fmt.Errorf("no versions selected")
 	}
 
-	// Step 3: Select scope (same for all)
+	// Step 3: Select image type (same for all)
+	imageType, err := i.SelectImageType()
+	if err != nil {
+		return err
+	}
+
+	// Step 4: Select scope (same for all)
 	scope, err := i.SelectInstallScope()
 	if err != nil {
 		return err
 	}
 
-	// Step 4: Install each version
+	// Step 5: Resolve download information for every version
 	fmt.Println()
 	fmt.Printf("Installing %d Java versions...\n", len(versions))
+
+	arch := i.arch()
+	infos := make([]*DownloadInfo, len(versions))
+	errs := make([]error, len(versions))
+
+	spinnerErr := WithSpinner(ctx, "Fetching download information...", func(ctx context.Context) error {
+		var wg sync.WaitGroup
+		for idx, version := range versions {
+			wg.Add(1)
+			go func(idx int, version string) {
+				defer wg.Done()
+				infos[idx], errs[idx] = i.resolveDownloadInfo(ctx, distributor, version, arch, imageType, i.options.EarlyAccess)
+			}(idx, version)
+		}
+		wg.Wait()
+		return nil
+	})
+	if spinnerErr != nil {
+		return spinnerErr
+	}
+
+	// Step 6: Download all archives concurrently
+	// Each run gets its own workspace so concurrent installs never collide
+	tempDir, err := newWorkspace()
+	if err != nil {
+		return err
+	}
+	defer closeWorkspace(tempDir)
+
+	archivePaths := make([]string, len(versions))
+	var jobs []DownloadJob
+	var jobVersions []int
+	for idx, version := range versions {
+		if errs[idx] != nil {
+			errs[idx] = fmt.Errorf("failed to get download URL: %w", errs[idx])
+			continue
+		}
+
+		// Reuse previously verified archives
+		if cachedPath := cachedArchive(infos[idx]); cachedPath != "" {
+			fmt.Printf("✓ Using cached archive for Java %s\n", version)
+			archivePaths[idx] = cachedPath
+			continue
+		}
+
+		archivePaths[idx] = filepath.Join(tempDir, infos[idx].FileName)
+		adoptPartial(archivePaths[idx])
+		jobs = append(jobs, DownloadJob{
+			Label:    "Java " + version,
+			URLs:     infos[idx].DownloadURLs(),
+			DestPath: archivePaths[idx],
+			Size:     infos[idx].Size,
+
+			Checksum:     infos[idx].Checksum,
+			ChecksumAlgo: infos[idx].ChecksumAlgo,
+		})
+		jobVersions = append(jobVersions, idx)
+	}
+
+	var totalSize int64
+	for _, job := range jobs {
+		totalSize += job.Size
+	}
+	if err := ensureFreeSpace(tempDir, totalSize); err != nil {
+		return err
+	}
+
+	for jobIdx, err := range DownloadAll(ctx, jobs) {
+		idx := jobVersions[jobIdx]
+		if err != nil {
+			errs[idx] = fmt.Errorf("download failed: %w", err)
+			continue
+		}
+		infos[idx].verified = infos[idx].Checksum != ""
+	}
 	fmt.Println()
 
-	installedPaths := []string{}
+	// Step 7: Verify, extract and install each downloaded archive
+	isSystemWide := (scope == "system" && i.isAdmin)
+	installed := []config.InstalledJDK{}
+
 	for idx, version := range versions {
+		if errs[idx] != nil {
+			continue
+		}
+		// Versions installed before a cancellation are still recorded
+		if ctx.Err() != nil {
+			errs[idx] = context.Cause(ctx)
+			continue
+		}
+
 		fmt.Printf("[%d/%d] Installing Java %s...\n", idx+1, len(versions), version)
 
-		installedPath, err := i.InstallVersion(distributor, version, scope)
+		receipt, err := InstallArchive(ctx, archivePaths[idx], infos[idx], version, distributor.Name(), isSystemWide)
 		if err != nil {
-			fmt.Printf("❌ Failed to install Java %s: %v\n", version, err)
+			errs[idx] = fmt.Errorf("installation failed: %w", err)
 			continue
 		}
 
-		installedPaths = append(installedPaths, installedPath)
-		fmt.Printf("✓ Java %s installed successfully\n\n", version)
+		installed = append(installed, *receipt)
+		fmt.Println()
 	}
 
-	// Step 5: Configure and save
-	return i.finalizeInstallation(installedPaths, versions, scope, distributor.Name())
-}
+	// Per-version summary
+	fmt.Println(theme.LabelStyle.Render("Summary:"))
+	for idx, version := range versions {
+		if errs[idx] != nil {
+			fmt.Printf("  ❌ Failed to install Java %s: %v\n", version, errs[idx])
+		} else {
+			fmt.Printf("  ✓ Java %s installed successfully\n", version)
+		}
+	}
 
-// finalizeInstallation handles config saving and environment setup
-func (i *Installer) finalizeInstallation(paths []string, versions []string, scope string, distributorName string) error {
-	// Add to config
-	for idx, path := range paths {
-		if strings.EqualFold(scope, "user") {
-			i.config.AddCustomPath(path)
+	if len(installed) == 0 {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
 		}
+		return fmt.Errorf("no Java versions were installed")
+	}
 
-		installedJDK := config.InstalledJDK{
-			Version:     versions[idx],
-			Path:        path,
-			Distributor: distributorName,
-			InstalledAt: time.Now().Format(time.RFC3339),
-			Scope:       scope,
+	// Step 8: Configure and save
+	return i.finalizeInstallation(ctx, installed)
+}
+
+// finalizeInstallation records the receipts of new installations in the
+// config and handles environment setup
+func (i *Installer) finalizeInstallation(ctx context.Context, installed []config.InstalledJDK) error {
+	// Add to config
+	for _, jdk := range installed {
+		// Debug and test images cannot be used as JAVA_HOME, so keep them out of detection
+		if strings.EqualFold(jdk.Scope, "user") && IsRunnableImage(jdk.ImageType) {
+			i.config.AddCustomPath(jdk.Path)
 		}
-		i.config.AddInstalledJDK(installedJDK)
+		i.config.AddInstalledJDK(jdk)
 	}
 
 	if err := i.config.Save(); err != nil {
@@ -167,8 +327,11 @@ func (i *Installer) finalizeInstallation(paths []string, versions []string, scop
 	}
 
 	// Configure environment for first installation if JAVA_HOME not set
-	if len(paths) > 0 {
-		if err := i.ConfigureEnvironment(paths[0]); err != nil {
+	if len(installed) > 0 && IsRunnableImage(installed[0].ImageType) {
+		if err := i.ConfigureEnvironment(ctx, installed[0].Path); err != nil {
+			if errors.Is(err, ErrCancelled) {
+				return err
+			}
 			fmt.Printf("\nNote: %v\n", err)
 		}
 	}
@@ -180,15 +343,15 @@ func (i *Installer) finalizeInstallation(paths []string, versions []string, scop
 	fmt.Println()
 
 	// Installation details
-	if len(paths) == 1 {
-		fmt.Println(theme.LabelStyle.Render(fmt.Sprintf("Java %s installed to:", versions[0])))
-		fmt.Printf("  %s\n", theme.PathStyle.Render(paths[0]))
+	if len(installed) == 1 {
+		fmt.Println(theme.LabelStyle.Render(fmt.Sprintf("Java %s (%s) installed to:", installed[0].Version, ImageTypeLabel(installed[0].ImageType))))
+		fmt.Printf("  %s\n", theme.PathStyle.Render(installed[0].Path))
 	} else {
-		fmt.Println(theme.LabelStyle.Render(fmt.Sprintf("Installed %d Java versions:", len(paths))))
-		for idx, version := range versions {
+		fmt.Println(theme.LabelStyle.Render(fmt.Sprintf("Installed %d Java versions:", len(installed))))
+		for _, jdk := range installed {
 			fmt.Printf("  • %s → %s\n",
-				theme.SuccessStyle.Render("Java "+version),
-				theme.PathStyle.Render(paths[idx]))
+				theme.SuccessStyle.Render("Java "+jdk.Version),
+				theme.PathStyle.Render(jdk.Path))
 		}
 	}
 
@@ -231,17 +394,56 @@ func (i *Installer) SelectInstallScope() (string, error) {
 	return scope, nil
 }
 
-// ShowDistributorMenu displays available distributors and returns the selected one
-func (i *Installer) ShowDistributorMenu() (Distributor, error) {
-	var selection string
+// SelectImageType asks user which package type to install
+func (i *Installer) SelectImageType() (string, error) {
+	var imageType string
 
 	err := huh.NewSelect[string]().
-		Title(theme.Subtitle.Render("Select Java Distributor")).
-		Description(theme.Faint.Render("More distributors coming soon")).
+		Title(theme.Subtitle.Render("Select Package Type")).
+		Description(theme.Faint.Render("Use arrow keys to navigate, Enter to select")).
 		Options(
-			huh.NewOption(theme.CurrentStyle.Render("Eclipse Adoptium")+" (Temurin)", "adoptium"),
-			// Coming soon: Azul Zulu, Amazon Corretto
+			huh.NewOption(theme.CurrentStyle.Render("JDK")+" (recommended) - development kit", ImageTypeJDK),
+			huh.NewOption(theme.CurrentStyle.Render("JRE")+" - runtime only", ImageTypeJRE),
+			huh.NewOption(theme.CurrentStyle.Render("Debug image")+" - debug symbols for the JDK", ImageTypeDebugImage),
+			huh.NewOption(theme.CurrentStyle.Render("Test image")+" - JTReg test image", ImageTypeTestImage),
 		).
+		Value(&imageType).
+		Run()
+
+	if err != nil {
+		return "", err
+	}
+
+	return imageType, nil
+}
+
+// ShowDistributorMenu displays available distributors and returns the selected one
+func (i *Installer) ShowDistributorMenu() (Distributor, error) {
+	keys := make([]int, 0, len(i.distributors))
+	for key := range i.distributors {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	var options []huh.Option[int]
+	for _, key := range keys {
+		d := i.distributors[key]
+		label := theme.CurrentStyle.Render(d.Name())
+		switch d.(type) {
+		case *AdoptiumDistributor:
+			label += " (Temurin)"
+		case *IndexDistributor, *PluginDistributor:
+			label += theme.Faint.Render(" (external)")
+		}
+		options = append(options, huh.NewOption(label, key))
+	}
+	// Coming soon: Azul Zulu, Amazon Corretto
+
+	var selection int
+	err := huh.NewSelect[int]().
+		Title(theme.Subtitle.Render("Select Java Distributor")).
+		Description(theme.Faint.Render("Add your own under external_distributors in the config")).
+		Options(options...).
 		Value(&selection).
 		Run()
 
@@ -249,21 +451,20 @@ func (i *Installer) ShowDistributorMenu() (Distributor, error) {
 		return nil, err
 	}
 
-	// Return the distributor based on selection
-	return i.distributors[1], nil // Adoptium for now
+	return i.distributors[selection], nil
 }
 
 // ShowVersionMenu displays available versions and returns the selected one
-func (i *Installer) ShowVersionMenu(distributor Distributor) (string, error) {
+func (i *Installer) ShowVersionMenu(ctx context.Context, distributor Distributor) (string, error) {
 	var releases []JavaRelease
 	var fetchErr error
 
 	// Fetch with spinner
-	spinnerErr := WithSpinner(
+	spinnerErr := WithSpinner(ctx,
 		fmt.Sprintf("Fetching available versions from %s...", distributor.Name()),
-		func() error {
+		func(ctx context.Context) error {
 			var err error
-			releases, err = distributor.GetAvailableVersions()
+			releases, err = i.availableVersions(ctx, distributor)
 			fetchErr = err
 			return nil // Don't propagate error, just store it
 		},
@@ -273,8 +474,9 @@ func (i *Installer) ShowVersionMenu(distributor Distributor) (string, error) {
 		return "", spinnerErr
 	}
 
-	if fetchErr != nil {
-		fmt.Printf("Warning: %v\n", fetchErr)
+	releases, source, err := i.fallbackVersions(distributor, releases, fetchErr)
+	if err != nil {
+		return "", err
 	}
 
 	// Get currently installed versions
@@ -315,6 +517,8 @@ func (i *Installer) ShowVersionMenu(distributor Distributor) (string, error) {
 		ltsCol := strings.Repeat(" ", len("[LTS]"))
 		if release.IsLTS {
 			ltsCol = theme.SuccessStyle.Render("[LTS]")
+		} else if release.IsEA {
+			ltsCol = theme.WarningStyle.Render("[EA]") + " "
 		}
 		instCol := strings.Repeat(" ", len("[Installed]"))
 		if installedMap[release.Version] {
@@ -336,10 +540,42 @@ func (i *Installer) ShowVersionMenu(distributor Distributor) (string, error) {
 	// Combine all options
 	allOptions := append(ltsOptions, featureOptions...)
 
+	title := "Select Java Version"
+	if i.options.EarlyAccess {
+		title = "Select Early-Access Java Version"
+	}
+	if source != "" {
+		title += " (" + source + ")"
+	}
+
+	// Describe the highlighted version's latest release. Notes the listing
+	// did not include are looked up as the cursor moves; huh caches the
+	// description per version.
+	notesByVersion := make(map[string]*ReleaseNotes)
+	for _, release := range releases {
+		if release.Notes != nil {
+			notesByVersion[release.Version] = release.Notes
+		}
+	}
 	var selected string
-	err := huh.NewSelect[string]().
-		Title(theme.Subtitle.Render("Select Java Version")).
-		Description(theme.Faint.Render("Use arrow keys to navigate, Enter to select")).
+	describe := func() string {
+		hint := theme.Faint.Render("Use arrow keys to navigate, Enter to select")
+		if selected == "" || i.options.EarlyAccess {
+			return hint
+		}
+		notes, ok := notesByVersion[selected]
+		if !ok {
+			notes, _ = FetchReleaseNotes(ctx, distributor, selected)
+		}
+		if notes == nil || notes.Release == "" {
+			return hint
+		}
+		return hint + "\n" + theme.InfoStyle.Render("Latest: "+ReleaseSummary(notes))
+	}
+
+	err = huh.NewSelect[string]().
+		Title(theme.Subtitle.Render(title)).
+		DescriptionFunc(describe, &selected).
 		Options(allOptions...).
 		Value(&selected).
 		Run()
@@ -352,16 +588,16 @@ func (i *Installer) ShowVersionMenu(distributor Distributor) (string, error) {
 }
 
 // SelectMultipleVersions allows installing multiple Java versions at once
-func (i *Installer) SelectMultipleVersions(distributor Distributor) ([]string, error) {
+func (i *Installer) SelectMultipleVersions(ctx context.Context, distributor Distributor) ([]string, error) {
 	var releases []JavaRelease
 	var fetchErr error
 
 	// Fetch with spinner
-	spinnerErr := WithSpinner(
+	spinnerErr := WithSpinner(ctx,
 		fmt.Sprintf("Fetching available versions from %s...", distributor.Name()),
-		func() error {
+		func(ctx context.Context) error {
 			var err error
-			releases, err = distributor.GetAvailableVersions()
+			releases, err = i.availableVersions(ctx, distributor)
 			fetchErr = err
 			return nil
 		},
@@ -371,8 +607,9 @@ func (i *Installer) SelectMultipleVersions(distributor Distributor) ([]string, e
 		return nil, spinnerErr
 	}
 
-	if fetchErr != nil {
-		return nil, fetchErr
+	releases, source, err := i.fallbackVersions(distributor, releases, fetchErr)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get installed versions
@@ -385,6 +622,11 @@ func (i *Installer) SelectMultipleVersions(distributor Distributor) ([]string, e
 		}
 	}
 
+	title := "Select Java Versions to Install"
+	if source != "" {
+		title += " (" + source + ")"
+	}
+
 	// Build options (aligned, with orange prefix and colored tags)
 	var options []huh.Option[string]
 	// determine max width
@@ -407,6 +649,8 @@ func (i *Installer) SelectMultipleVersions(distributor Distributor) ([]string, e
 		ltsCol := strings.Repeat(" ", len("[LTS]"))
 		if release.IsLTS {
 			ltsCol = theme.SuccessStyle.Render("[LTS]")
+		} else if release.IsEA {
+			ltsCol = theme.WarningStyle.Render("[EA]") + " "
 		}
 		instCol := strings.Repeat(" ", len("[Installed]"))
 		if installedMap[release.Version] {
@@ -420,8 +664,8 @@ func (i *Installer) SelectMultipleVersions(distributor Distributor) ([]string, e
 
 	var selected []string
 
-	err := huh.NewMultiSelect[string]().
-		Title(theme.Subtitle.Render("Select Java Versions to Install")).
+	err = huh.NewMultiSelect[string]().
+		Title(theme.Subtitle.Render(title)).
 		Description(theme.Faint.Render("Use Space to select, Enter to confirm")).
 		Options(options...).
 		Value(&selected).
@@ -455,58 +699,164 @@ func (i *Installer) SelectInstallMode() (string, error) {
 	return mode, nil
 }
 
-// InstallVersion downloads and installs the selected version
-func (i *Installer) InstallVersion(distributor Distributor, version string, scope string) (string, error) {
+// InstallVersion downloads and installs the selected version and image type.
+// It returns the receipt of the new installation.
+func (i *Installer) InstallVersion(ctx context.Context, distributor Distributor, version string, imageType string, scope string) (*config.InstalledJDK, error) {
 	// Installation header with JV theme
 	fmt.Println()
-	fmt.Println(theme.Subtitle.Render(fmt.Sprintf("Installing Java %s from %s", version, distributor.Name())))
+	label := version
+	if i.options.EarlyAccess {
+		label += " early access"
+	}
+	fmt.Println(theme.Subtitle.Render(fmt.Sprintf("Installing Java %s (%s) from %s", label, ImageTypeLabel(imageType), distributor.Name())))
 	fmt.Println()
 
-	// Get system architecture
-	arch := runtime.GOARCH
+	// Get target architecture
+	arch := i.arch()
 
 	// Get download URL with spinner
 	var downloadInfo *DownloadInfo
+	var notes *ReleaseNotes
 	var fetchErr error
 
-	spinnerErr := WithSpinner(
+	spinnerErr := WithSpinner(ctx,
 		"Fetching download information...",
-		func() error {
+		func(ctx context.Context) error {
 			var err error
-			downloadInfo, err = distributor.GetDownloadURL(version, arch)
+			downloadInfo, err = i.resolveDownloadInfo(ctx, distributor, version, arch, imageType, i.options.EarlyAccess)
 			fetchErr = err
+			if err == nil && downloadInfo.Release != "" && !i.options.EarlyAccess {
+				notes, _ = FetchReleaseNotes(ctx, distributor, downloadInfo.Release)
+			}
 			return nil
 		},
 	)
 
 	if spinnerErr != nil {
-		return "", spinnerErr
+		return nil, spinnerErr
 	}
 
 	if fetchErr != nil {
-		return "", fmt.Errorf("failed to get download URL: %w", fetchErr)
+		return nil, fmt.Errorf("failed to get download URL: %w", fetchErr)
 	}
 
 	// Styled package info with JV theme
 	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Package:"), theme.ValueStyle.Render(downloadInfo.FileName))
+	if downloadInfo.Release != "" {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Release:"), theme.ValueStyle.Render(downloadInfo.Release))
+	}
+	if notes != nil {
+		printReleaseNotes(notes)
+	}
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Type:   "), theme.ValueStyle.Render(ImageTypeLabel(downloadInfo.ImageType)))
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Arch:   "), theme.ValueStyle.Render(ArchLabel(downloadInfo.Arch)))
 	sizeMB := float64(downloadInfo.Size) / 1024 / 1024
 	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Size:   "), theme.ValueStyle.Render(fmt.Sprintf("%.2f MB", sizeMB)))
+	if len(downloadInfo.Mirrors) > 0 {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Mirrors:"), theme.ValueStyle.Render(fmt.Sprintf("%d configured", len(downloadInfo.Mirrors))))
+	}
 	fmt.Println()
 
 	// Determine isSystemWide based on scope
 	isSystemWide := (scope == "system" && i.isAdmin)
 
 	// Install JDK
-	installedPath, err := InstallJDK(downloadInfo, version, distributor.Name(), isSystemWide)
+	installed, err := InstallJDK(ctx, downloadInfo, version, distributor.Name(), isSystemWide)
 	if err != nil {
-		return "", fmt.Errorf("installation failed: %w", err)
+		return nil, fmt.Errorf("installation failed: %w", err)
+	}
+
+	return installed, nil
+}
+
+// fallbackVersions decides what a version menu shows when listing versions
+// failed: the distributor's last listing, or offline the versions in the
+// archive cache. The source describes where they came from for the menu title.
+func (i *Installer) fallbackVersions(distributor Distributor, releases []JavaRelease, fetchErr error) ([]JavaRelease, string, error) {
+	if fetchErr == nil {
+		return releases, "", nil
+	}
+	// Early-access listings have no fallback
+	if i.options.EarlyAccess {
+		return nil, "", fetchErr
+	}
+
+	var stale *StaleVersionsError
+	if errors.As(fetchErr, &stale) {
+		fmt.Println(theme.WarningMessage(fmt.Sprintf("Could not list versions from %s, showing the list %s", distributor.Name(), CachedAge(stale.StoredAt))))
+		fmt.Println(theme.Faint.Render("  " + stale.Err.Error()))
+		return releases, CachedAge(stale.StoredAt), nil
+	}
+
+	if i.options.Offline {
+		if cached := cachedReleases(distributor); len(cached) > 0 {
+			fmt.Printf("Warning: %v\n", fetchErr)
+			fmt.Println(theme.InfoMessage("Offline: showing versions available in the archive cache"))
+			return cached, "archive cache", nil
+		}
+	}
+	return nil, "", fetchErr
+}
+
+// arch returns the architecture to install for
+func (i *Installer) arch() string {
+	if i.options.Arch != "" {
+		return i.options.Arch
+	}
+	return HostArch()
+}
+
+// availableVersions lists the GA versions a distributor offers, falling back
+// to its last listing, or with --ea the versions it publishes early-access
+// builds of
+func (i *Installer) availableVersions(ctx context.Context, distributor Distributor) ([]JavaRelease, error) {
+	if !i.options.EarlyAccess {
+		return listVersions(ctx, distributor)
+	}
+	ea, ok := distributor.(EarlyAccessDistributor)
+	if !ok {
+		return nil, fmt.Errorf("%s does not publish early-access builds", distributor.Name())
 	}
+	return ea.GetEarlyAccessVersions(ctx)
+}
 
-	return installedPath, nil
+// resolveDownloadInfo asks the distributor for the latest GA or early-access
+// build, adding the configured download mirrors. In offline mode GA releases
+// fall back to the newest matching archive in the cache.
+func (i *Installer) resolveDownloadInfo(ctx context.Context, distributor Distributor, version string, arch string, imageType string, earlyAccess bool) (*DownloadInfo, error) {
+	var info *DownloadInfo
+	var err error
+	if earlyAccess {
+		ea, ok := distributor.(EarlyAccessDistributor)
+		if !ok {
+			return nil, fmt.Errorf("%s does not publish early-access builds", distributor.Name())
+		}
+		info, err = ea.GetEarlyAccessDownloadURL(ctx, version, arch, imageType)
+	} else {
+		info, err = distributor.GetDownloadURL(ctx, version, arch, imageType)
+	}
+	if err == nil {
+		if info.Checksum == "" && !i.options.InsecureSkipChecksum {
+			return nil, fmt.Errorf("%w for %s from %s; use --insecure-skip-checksum to install it unverified", ErrNoChecksum, info.FileName, distributor.Name())
+		}
+		info.mirrorRules = i.config.Distributor(distributor.ID()).Mirrors
+		info.Mirrors = mirrorURLs(info.URL, info.mirrorRules)
+		return info, nil
+	}
+	// The cache does not tell early-access archives from GA ones
+	if !i.options.Offline || earlyAccess {
+		return nil, err
+	}
+
+	cached, cacheErr := cachedDownloadInfo(distributor, version, imageType, arch)
+	if cacheErr != nil {
+		return nil, fmt.Errorf("%w (offline fallback: %v)", err, cacheErr)
+	}
+	return cached, nil
 }
 
 // ConfigureEnvironment sets JAVA_HOME if not already set
-func (i *Installer) ConfigureEnvironment(jdkPath string) error {
+func (i *Installer) ConfigureEnvironment(ctx context.Context, jdkPath string) error {
 	// Check if JAVA_HOME is already set
 	currentJavaHome := os.Getenv("JAVA_HOME")
 	if currentJavaHome != "" {
@@ -535,9 +885,20 @@ func (i *Installer) ConfigureEnvironment(jdkPath string) error {
 	// Set JAVA_HOME
 	fmt.Println()
 	fmt.Println(theme.InfoStyle.Render("Configuring JAVA_HOME..."))
+	hookCtx := HookContext(jdkPath, "")
+	if err := hooks.Run(ctx, hooks.PreSwitch, hookCtx); err != nil {
+		return err
+	}
 	if err := env.SetJavaHome(jdkPath); err != nil {
 		return fmt.Errorf("failed to set JAVA_HOME: %w", err)
 	}
+	i.config.RecordSwitch(jdkPath)
+	if err := i.config.Save(); err != nil {
+		fmt.Printf("Warning: Failed to save config: %v\n", err)
+	}
+	if err := hooks.Run(ctx, hooks.PostSwitch, hookCtx); err != nil {
+		fmt.Println(theme.WarningMessage(err.Error()))
+	}
 
 	fmt.Println(theme.SuccessMessage("JAVA_HOME configured successfully"))
 	fmt.Printf("  JAVA_HOME = %s\n", theme.PathStyle.Render(jdkPath))
@@ -0,0 +1,51 @@
+package installer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"jv/internal/config"
+)
+
+func TestResolveDownloadInfoEarlyAccess(t *testing.T) {
+	useTempCache(t)
+	index := newTestIndex(t, "index.yaml", testIndex)
+
+	tests := []struct {
+		name        string
+		distributor Distributor
+		version     string
+		offline     bool
+		wantRelease string
+		wantErr     string
+	}{
+		{name: "early-access build", distributor: index, version: "24", wantRelease: "24-ea+20"},
+		{name: "no GA fallback", distributor: index, version: "21", wantErr: "no early-access JDK found"},
+		// The archive cache does not tell early-access archives from GA ones
+		{name: "no offline fallback", distributor: index, version: "23", offline: true, wantErr: "no early-access JDK found for Java 23"},
+		{name: "distributor without early access", distributor: &fakeDistributor{}, version: "24", wantErr: "Fake does not publish early-access builds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &Installer{config: &config.Config{}, options: Options{EarlyAccess: true, Offline: tt.offline}}
+			info, err := inst.resolveDownloadInfo(context.Background(), tt.distributor, tt.version, ArchX64, ImageTypeJDK, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveDownloadInfo() error = %v, want %q", err, tt.wantErr)
+				}
+				if strings.Contains(err.Error(), "offline fallback") {
+					t.Errorf("resolveDownloadInfo() error = %v, want no offline fallback", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDownloadInfo(): %v", err)
+			}
+			if info.Release != tt.wantRelease || !info.EarlyAccess {
+				t.Errorf("resolveDownloadInfo() = %s (early access %v), want %s as early access", info.Release, info.EarlyAccess, tt.wantRelease)
+			}
+		})
+	}
+}
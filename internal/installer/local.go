@@ -0,0 +1,151 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"jv/internal/config"
+	"jv/internal/theme"
+)
+
+// CustomDistributor is recorded for JDKs installed from a local archive or an
+// arbitrary URL rather than from a known distributor
+const CustomDistributor = "Custom"
+
+// ArchiveSource describes a JDK archive given on the command line
+type ArchiveSource struct {
+	Path   string // Local archive, e.g. from "jv install --from"
+	URL    string // Remote archive, e.g. from "jv install --url"
+	SHA256 string // Expected checksum; without one a checksum file next to the archive is used
+}
+
+// RunArchiveInstall installs a JDK from a local archive or an arbitrary URL
+// through the same verification, extraction and bookkeeping as a distributor
+// install. The version is read from the archive's release file.
+func (i *Installer) RunArchiveInstall(ctx context.Context, source ArchiveSource) error {
+	if (source.Path == "") == (source.URL == "") {
+		return fmt.Errorf("specify exactly one of --from <archive> or --url <url>")
+	}
+
+	downloadInfo, err := archiveDownloadInfo(source)
+	if err != nil {
+		return err
+	}
+
+	// Look for a checksum file published next to the archive, or one copied
+	// next to a local archive. Hashing a local archive would only compare it
+	// with itself.
+	if downloadInfo.Checksum == "" {
+		location := source.URL
+		if location == "" {
+			location = source.Path
+		}
+		digest, algo, err := detachedChecksum(ctx, location, downloadInfo.FileName, nil)
+		if err != nil && !i.options.InsecureSkipChecksum {
+			return fmt.Errorf("%w; pass --sha256 <checksum>, or --insecure-skip-checksum to install it unverified", err)
+		}
+		downloadInfo.Checksum = digest
+		downloadInfo.ChecksumAlgo = algo
+	}
+	// Without --arch the architecture is read from the archive's release file
+	downloadInfo.Arch = i.options.Arch
+
+	fmt.Println()
+	fmt.Println(theme.Subtitle.Render("Installing Java from " + sourceLabel(source)))
+	fmt.Println()
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Package:"), theme.ValueStyle.Render(downloadInfo.FileName))
+	if downloadInfo.Checksum != "" {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render(ChecksumLabel(downloadInfo.ChecksumAlgo)+":"), theme.ValueStyle.Render(downloadInfo.Checksum))
+	} else {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Checksum:"), theme.WarningStyle.Render("none (--insecure-skip-checksum)"))
+	}
+
+	scope, err := i.SelectInstallScope()
+	if err != nil {
+		return err
+	}
+	isSystemWide := (scope == "system" && i.isAdmin)
+
+	// Each run gets its own workspace so concurrent installs never collide
+	tempDir, err := newWorkspace()
+	if err != nil {
+		return err
+	}
+	defer closeWorkspace(tempDir)
+
+	archivePath := source.Path
+	if source.URL != "" {
+		archivePath, err = fetchArchive(ctx, downloadInfo, tempDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	installed, err := InstallArchive(ctx, archivePath, downloadInfo, "", CustomDistributor, isSystemWide)
+	if err != nil {
+		return fmt.Errorf("installation failed: %w", err)
+	}
+
+	return i.finalizeInstallation(ctx, []config.InstalledJDK{*installed})
+}
+
+// archiveDownloadInfo builds the download information for an archive source
+func archiveDownloadInfo(source ArchiveSource) (*DownloadInfo, error) {
+	info := &DownloadInfo{
+		Checksum:     strings.ToLower(strings.TrimSpace(source.SHA256)),
+		ChecksumAlgo: ChecksumSHA256,
+	}
+	if info.Checksum != "" {
+		if err := validateDigest(info.Checksum, ChecksumSHA256); err != nil {
+			return nil, fmt.Errorf("invalid --sha256 value: %w", err)
+		}
+	}
+
+	if source.URL != "" {
+		u, err := url.Parse(source.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+			return nil, fmt.Errorf("invalid archive URL %q", source.URL)
+		}
+		info.URL = source.URL
+		// The name becomes part of the download and cache paths; a decoded
+		// path such as "..%5C..%5Cjdk.zip" must not escape them
+		info.FileName, err = packageFileName(path.Base(u.Path))
+		if err != nil {
+			info.FileName = "jdk-archive"
+		}
+		return info, nil
+	}
+
+	stat, err := os.Stat(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read archive: %w", err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory; use 'jv add' to register an unpacked JDK", source.Path)
+	}
+	info.FileName = filepath.Base(source.Path)
+	info.Size = stat.Size()
+	return info, nil
+}
+
+// detectImageType tells a JRE from a JDK by the presence of javac
+func detectImageType(jdkPath string) string {
+	for _, name := range []string{"javac.exe", "javac"} {
+		if _, err := os.Stat(filepath.Join(jdkPath, "bin", name)); err == nil {
+			return ImageTypeJDK
+		}
+	}
+	return ImageTypeJRE
+}
+
+func sourceLabel(source ArchiveSource) string {
+	if source.URL != "" {
+		return source.URL
+	}
+	return source.Path
+}
@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArchiveDownloadInfo(t *testing.T) {
+	const digest = "3f7d9a0c5e1b2d4f6a8c0e2b4d6f8a0c2e4b6d8f0a2c4e6b8d0f2a4c6e8b0d2f"
+
+	tests := []struct {
+		name         string
+		source       ArchiveSource
+		wantFileName string
+		wantChecksum string
+		wantErr      string
+	}{
+		{
+			name:         "plain URL",
+			source:       ArchiveSource{URL: "https://example.com/jdks/jdk-21.zip"},
+			wantFileName: "jdk-21.zip",
+		},
+		{
+			name:         "checksum normalized",
+			source:       ArchiveSource{URL: "https://example.com/jdk-21.zip", SHA256: " " + strings.ToUpper(digest) + "\n"},
+			wantFileName: "jdk-21.zip",
+			wantChecksum: digest,
+		},
+		{
+			name:         "no file name",
+			source:       ArchiveSource{URL: "https://example.com/"},
+			wantFileName: "jdk-archive",
+		},
+		{
+			name:         "encoded backslashes",
+			source:       ArchiveSource{URL: "https://example.com/..%5C..%5Cjdk.zip"},
+			wantFileName: "jdk-archive",
+		},
+		{
+			name:         "encoded drive",
+			source:       ArchiveSource{URL: "https://example.com/C:jdk.zip"},
+			wantFileName: "jdk-archive",
+		},
+		{
+			name:         "dot dot",
+			source:       ArchiveSource{URL: "https://example.com/jdks/.."},
+			wantFileName: "jdk-archive",
+		},
+		{
+			name:    "unsupported scheme",
+			source:  ArchiveSource{URL: "ftp://example.com/jdk-21.zip"},
+			wantErr: "invalid archive URL",
+		},
+		{
+			name:    "checksum too short",
+			source:  ArchiveSource{URL: "https://example.com/jdk-21.zip", SHA256: digest[:40]},
+			wantErr: "invalid --sha256 value",
+		},
+		{
+			name:    "checksum not hex",
+			source:  ArchiveSource{URL: "https://example.com/jdk-21.zip", SHA256: "sha256:" + digest},
+			wantErr: "not hexadecimal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := archiveDownloadInfo(tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("archiveDownloadInfo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("archiveDownloadInfo(): %v", err)
+			}
+			if info.FileName != tt.wantFileName {
+				t.Errorf("FileName = %q, want %q", info.FileName, tt.wantFileName)
+			}
+			if info.Checksum != tt.wantChecksum {
+				t.Errorf("Checksum = %q, want %q", info.Checksum, tt.wantChecksum)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"jv/internal/config"
+	"jv/internal/httpclient"
+)
+
+// probeTimeout bounds each reachability check run by ProbeEndpoints
+const probeTimeout = 5 * time.Second
+
+// mirrorURLs applies the mirror rules to a download URL, returning the rewritten
+// URLs in rule order. Rules whose prefix does not match are skipped.
+func mirrorURLs(downloadURL string, rules []config.MirrorRule) []string {
+	var urls []string
+	for _, rule := range rules {
+		if rule.Prefix == "" || rule.Replacement == "" {
+			continue
+		}
+		if strings.HasPrefix(downloadURL, rule.Prefix) {
+			urls = append(urls, rule.Replacement+strings.TrimPrefix(downloadURL, rule.Prefix))
+		}
+	}
+	return urls
+}
+
+// fetchSmallMirrored downloads a small file published next to a download,
+// such as its signature or checksum file, trying the mirrors before the
+// original URL like DownloadURLs. The error only counts as not found when
+// every URL answered 404; otherwise the first other error is returned.
+func fetchSmallMirrored(ctx context.Context, fileURL string, rules []config.MirrorRule) ([]byte, error) {
+	var notFoundErr, fetchErr error
+	for _, u := range append(mirrorURLs(fileURL, rules), fileURL) {
+		data, err := fetchSmall(ctx, u)
+		switch {
+		case err == nil:
+			return data, nil
+		case ctx.Err() != nil:
+			return nil, err
+		case isNotFound(err):
+			if notFoundErr == nil {
+				notFoundErr = err
+			}
+		case fetchErr == nil:
+			fetchErr = err
+		}
+	}
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	return nil, notFoundErr
+}
+
+// EndpointStatus is the result of probing a distributor API or download mirror
+type EndpointStatus struct {
+	Distributor string
+	Kind        string // "api", "mirror" or "origin"
+	Name        string
+	URL         string
+	Reachable   bool
+	Latency     time.Duration
+	Err         error
+}
+
+// adoptiumAssetOrigins are the hosts Adoptium binaries are downloaded from:
+// GitHub release download links redirect to GitHub's asset host. Each
+// feature release has its own repository, so only the hosts are probed.
+var adoptiumAssetOrigins = []EndpointStatus{
+	{Name: "github.com (release downloads)", URL: "https://github.com/"},
+	{Name: "release-assets.githubusercontent.com (release assets)", URL: "https://release-assets.githubusercontent.com/"},
+}
+
+// ProbeEndpoints checks which API endpoints, download mirrors and download
+// hosts of the built-in and external distributors answer. Any HTTP response
+// counts as reachable; only transport errors fail.
+func ProbeEndpoints(cfg *config.Config) []EndpointStatus {
+	var targets []EndpointStatus
+	add := func(distributor, kind, name, targetURL string) {
+		targets = append(targets, EndpointStatus{Distributor: distributor, Kind: kind, Name: name, URL: targetURL})
+	}
+	addMirrors := func(distributor string) {
+		for _, rule := range cfg.Distributor(distributor).Mirrors {
+			name := rule.Name
+			if name == "" {
+				name = rule.Replacement
+			}
+			add(distributor, "mirror", name, rule.Replacement)
+		}
+	}
+
+	apiBases := cfg.Distributor(AdoptiumID).APIBaseURLs
+	if len(apiBases) == 0 {
+		apiBases = []string{DefaultAdoptiumAPIBase}
+	}
+	for _, base := range apiBases {
+		add(AdoptiumID, "api", base, strings.TrimRight(base, "/")+"/info/available_releases")
+	}
+	addMirrors(AdoptiumID)
+	for _, origin := range adoptiumAssetOrigins {
+		add(AdoptiumID, "origin", origin.Name, origin.URL)
+	}
+
+	add(OpenJFXID, "api", openJFXMetadataURL, openJFXMetadataURL)
+	addMirrors(OpenJFXID)
+	add(OpenJFXID, "origin", openJFXDownloadBase, openJFXDownloadBase+"/")
+
+	// Plugins run locally, and the packages of an index may live anywhere,
+	// so only an index on a web server and the mirrors can be checked
+	for _, settings := range cfg.ExternalDistributors {
+		id := strings.ToLower(strings.TrimSpace(settings.ID))
+		if u, err := url.Parse(settings.Index); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			add(id, "api", settings.Index, settings.Index)
+		}
+		addMirrors(id)
+	}
+
+	client, err := httpclient.Client(probeTimeout)
+	if err != nil {
+		for idx := range targets {
+			targets[idx].Err = err
+		}
+		return targets
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for idx := range targets {
+		t := &targets[idx]
+		if _, err := url.Parse(t.URL); err != nil {
+			t.Err = err
+			continue
+		}
+
+		start := time.Now()
+		resp, err := client.Head(t.URL)
+		t.Latency = time.Since(start)
+		if err != nil {
+			t.Err = err
+			continue
+		}
+		resp.Body.Close()
+		t.Reachable = true
+	}
+
+	return targets
+}
@@ -0,0 +1,139 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jv/internal/config"
+)
+
+func TestMirrorURLs(t *testing.T) {
+	const download = "https://github.com/adoptium/temurin21-binaries/releases/download/jdk-21.0.5%2B11/OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip"
+
+	tests := []struct {
+		name  string
+		rules []config.MirrorRule
+		want  []string
+	}{
+		{name: "no rules"},
+		{
+			name:  "matching prefix",
+			rules: []config.MirrorRule{{Prefix: "https://github.com/", Replacement: "https://artifactory.example.com/github/"}},
+			want:  []string{"https://artifactory.example.com/github/adoptium/temurin21-binaries/releases/download/jdk-21.0.5%2B11/OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip"},
+		},
+		{
+			name: "rule order kept",
+			rules: []config.MirrorRule{
+				{Name: "office", Prefix: "https://github.com/adoptium/", Replacement: "http://mirror.office/adoptium/"},
+				{Name: "cloud", Prefix: "https://github.com/", Replacement: "https://cache.example.com/"},
+			},
+			want: []string{
+				"http://mirror.office/adoptium/temurin21-binaries/releases/download/jdk-21.0.5%2B11/OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip",
+				"https://cache.example.com/adoptium/temurin21-binaries/releases/download/jdk-21.0.5%2B11/OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip",
+			},
+		},
+		{
+			name:  "other prefix skipped",
+			rules: []config.MirrorRule{{Prefix: "https://download.oracle.com/", Replacement: "https://mirror.example.com/"}},
+		},
+		{
+			name:  "prefix is case sensitive",
+			rules: []config.MirrorRule{{Prefix: "https://GitHub.com/", Replacement: "https://mirror.example.com/"}},
+		},
+		{
+			name: "incomplete rules skipped",
+			rules: []config.MirrorRule{
+				{Prefix: "", Replacement: "https://mirror.example.com/"},
+				{Prefix: "https://github.com/", Replacement: ""},
+			},
+		},
+		{
+			name:  "file share",
+			rules: []config.MirrorRule{{Prefix: "https://github.com/adoptium/temurin21-binaries/releases/download/", Replacement: `\\share\jdks\`}},
+			want:  []string{`\\share\jdks\jdk-21.0.5%2B11/OpenJDK21U-jdk_x64_windows_hotspot_21.0.5_11.zip`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mirrorURLs(download, tt.rules)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("mirrorURLs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadURLs(t *testing.T) {
+	info := &DownloadInfo{
+		URL:     "https://github.com/jdk.zip",
+		Mirrors: []string{"https://mirror-a/jdk.zip", "", "https://mirror-b/jdk.zip", "https://mirror-a/jdk.zip", "https://github.com/jdk.zip"},
+	}
+
+	want := []string{"https://mirror-a/jdk.zip", "https://mirror-b/jdk.zip", "https://github.com/jdk.zip"}
+	if got := info.DownloadURLs(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DownloadURLs() = %q, want %q", got, want)
+	}
+}
+
+func TestFetchSmallMirrored(t *testing.T) {
+	files := map[string]string{"/origin/jdk.zip.sig": "origin signature", "/mirror/jdk.zip.sig": "mirror signature"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/broken/") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	origin := server.URL + "/origin/"
+	rule := func(dir string) config.MirrorRule {
+		return config.MirrorRule{Prefix: origin, Replacement: server.URL + "/" + dir + "/"}
+	}
+
+	tests := []struct {
+		name         string
+		file         string
+		rules        []config.MirrorRule
+		want         string
+		wantNotFound bool
+		wantErr      string
+	}{
+		{name: "no mirrors", file: "jdk.zip.sig", want: "origin signature"},
+		{name: "mirror first", file: "jdk.zip.sig", rules: []config.MirrorRule{rule("mirror")}, want: "mirror signature"},
+		{name: "missing on mirror", file: "jdk.zip.sig", rules: []config.MirrorRule{rule("empty")}, want: "origin signature"},
+		{name: "failing mirror", file: "jdk.zip.sig", rules: []config.MirrorRule{rule("broken")}, want: "origin signature"},
+		{name: "missing everywhere", file: "jdk.zip.asc", rules: []config.MirrorRule{rule("mirror")}, wantNotFound: true},
+		// A mirror that fails is not evidence that no signature exists
+		{name: "missing on origin, failing mirror", file: "jdk.zip.asc", rules: []config.MirrorRule{rule("broken")}, wantErr: "status 403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fetchSmallMirrored(context.Background(), origin+tt.file, tt.rules)
+			switch {
+			case tt.wantNotFound:
+				if !isNotFound(err) {
+					t.Fatalf("fetchSmallMirrored() error = %v, want not found", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || isNotFound(err) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchSmallMirrored() error = %v, want %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("fetchSmallMirrored(): %v", err)
+			case string(data) != tt.want:
+				t.Errorf("fetchSmallMirrored() = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"jv/internal/theme"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxConcurrentDownloads bounds how many archives a batch install fetches at once
+const maxConcurrentDownloads = 3
+
+// progressUpdateInterval throttles UI updates from download writers
+const progressUpdateInterval = 100 * time.Millisecond
+
+// DownloadJob describes one archive to fetch in a batch download
+type DownloadJob struct {
+	Label    string   // Shown next to the progress bar, e.g. "Java 21"
+	URLs     []string // Mirrors of the same file, tried in order
+	DestPath string
+	Size     int64 // Expected size, used for the overall bar before the download starts
+
+	Checksum     string // Verified while downloading when set
+	ChecksumAlgo string
+}
+
+type downloadState int
+
+const (
+	downloadWaiting downloadState = iota
+	downloadRunning
+	downloadRetrying
+	downloadDone
+	downloadFailed
+)
+
+// downloadItem is the per-job state rendered by multiProgressModel
+type downloadItem struct {
+	label      string
+	state      downloadState
+	downloaded int64
+	total      int64
+	speed      string
+	note       string
+}
+
+type multiProgressMsg struct {
+	index      int
+	downloaded int64
+	total      int64
+	speed      string
+}
+
+type multiStateMsg struct {
+	index int
+	state downloadState
+	note  string
+}
+
+type multiDoneMsg struct{}
+
+// multiProgressModel renders one progress bar per download plus an overall bar
+type multiProgressModel struct {
+	items     []downloadItem
+	bar       progress.Model
+	labelW    int
+	quitting  bool
+	cancelled bool // Ctrl+C was pressed; DownloadAll stops the downloads
+}
+
+func newMultiProgressModel(jobs []DownloadJob) multiProgressModel {
+	items := make([]downloadItem, len(jobs))
+	labelW := len("Overall")
+	for idx, job := range jobs {
+		items[idx] = downloadItem{label: job.Label, total: job.Size, speed: "0 B/s"}
+		if w := lipgloss.Width(job.Label); w > labelW {
+			labelW = w
+		}
+	}
+
+	return multiProgressModel{
+		items: items,
+		bar: progress.New(
+			progress.WithDefaultGradient(),
+			progress.WithWidth(30),
+			progress.WithoutPercentage(),
+		),
+		labelW: labelW,
+	}
+}
+
+func (m multiProgressModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m multiProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.quitting = true
+			m.cancelled = true
+			return m, tea.Quit
+		}
+		return m, nil
+
+	case multiProgressMsg:
+		item := &m.items[msg.index]
+		item.downloaded = msg.downloaded
+		if msg.total > 0 {
+			item.total = msg.total
+		}
+		item.speed = msg.speed
+		return m, nil
+
+	case multiStateMsg:
+		item := &m.items[msg.index]
+		item.state = msg.state
+		item.note = msg.note
+		if msg.state == downloadDone && item.total > 0 {
+			item.downloaded = item.total
+		}
+		return m, nil
+
+	case multiDoneMsg:
+		m.quitting = true
+		return m, tea.Quit
+
+	default:
+		return m, nil
+	}
+}
+
+func (m multiProgressModel) View() string {
+	pad := strings.Repeat(" ", padding)
+	var b strings.Builder
+	b.WriteString("\n")
+
+	var totalDownloaded, totalSize int64
+	completed := 0
+
+	for _, item := range m.items {
+		totalDownloaded += item.downloaded
+		totalSize += item.total
+
+		label := item.label + strings.Repeat(" ", m.labelW-lipgloss.Width(item.label))
+		percent := 0.0
+		if item.total > 0 {
+			percent = float64(item.downloaded) / float64(item.total)
+		}
+
+		var info string
+		switch item.state {
+		case downloadWaiting:
+			info = helpStyle("waiting")
+		case downloadRunning:
+			info = helpStyle(fmt.Sprintf("%s / %s - %s", FormatSize(item.downloaded), FormatSize(item.total), item.speed))
+		case downloadRetrying:
+			info = theme.WarningStyle.Render(item.note)
+		case downloadDone:
+			completed++
+			info = theme.SuccessStyle.Render("✓ " + FormatSize(item.total))
+		case downloadFailed:
+			completed++
+			info = theme.ErrorStyle.Render("✗ " + item.note)
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s  %s  %s\n", pad, theme.CurrentStyle.Render(label), m.bar.ViewAs(percent), info))
+	}
+
+	overall := 0.0
+	if totalSize > 0 {
+		overall = float64(totalDownloaded) / float64(totalSize)
+	}
+	label := "Overall" + strings.Repeat(" ", m.labelW-len("Overall"))
+	info := fmt.Sprintf("%s / %s (%.0f%%) - %d/%d complete",
+		FormatSize(totalDownloaded), FormatSize(totalSize), overall*100, completed, len(m.items))
+
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("%s%s  %s  %s\n", pad, theme.LabelStyle.Render(label), m.bar.ViewAs(overall), helpStyle(info)))
+
+	// The final frame stays on screen after quitting as the download summary
+	return b.String()
+}
+
+// batchProgressWriter reports bytes for one job of a batch download
+type batchProgressWriter struct {
+	index       int
+	total       int64
+	downloaded  int64
+	startOffset int64
+	startTime   time.Time
+	lastSent    time.Time
+	program     *tea.Program
+}
+
+func (w *batchProgressWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.downloaded += int64(n)
+
+	if time.Since(w.lastSent) >= progressUpdateInterval || w.downloaded == w.total {
+		w.lastSent = time.Now()
+		speed := "0 B/s"
+		if elapsed := time.Since(w.startTime).Seconds(); elapsed > 0 {
+			speed = FormatSize(int64(float64(w.downloaded-w.startOffset)/elapsed)) + "/s"
+		}
+		w.program.Send(multiProgressMsg{index: w.index, downloaded: w.downloaded, total: w.total, speed: speed})
+	}
+
+	return n, nil
+}
+
+// DownloadAll downloads jobs concurrently, at most maxConcurrentDownloads at a
+// time, rendering one progress bar per job and an overall bar. Each download
+// retries and resumes like DownloadFile, and all stop when ctx is cancelled
+// or Ctrl+C is pressed. The returned slice holds the error for each job, nil
+// on success.
+func DownloadAll(ctx context.Context, jobs []DownloadJob) []error {
+	errs := make([]error, len(jobs))
+	if len(jobs) == 0 {
+		return errs
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	p := tea.NewProgram(newMultiProgressModel(jobs))
+	uiDone := make(chan struct{})
+	go func() {
+		defer close(uiDone)
+		final, err := p.Run()
+		if m, ok := final.(multiProgressModel); (ok && m.cancelled) || errors.Is(err, tea.ErrInterrupted) {
+			cancel(ErrCancelled)
+		} else if err != nil {
+			fmt.Printf("Error running progress: %v\n", err)
+		}
+	}()
+
+	// Give the UI a moment to start
+	time.Sleep(100 * time.Millisecond)
+
+	sem := make(chan struct{}, maxConcurrentDownloads)
+	var wg sync.WaitGroup
+
+	for idx, job := range jobs {
+		wg.Add(1)
+		go func(idx int, job DownloadJob) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[idx] = context.Cause(ctx)
+				p.Send(multiStateMsg{index: idx, state: downloadFailed, note: "cancelled"})
+				return
+			}
+
+			pw := &batchProgressWriter{index: idx, program: p}
+
+			onStart := func(offset int64, total int64) io.Writer {
+				pw.total = total
+				pw.downloaded = offset
+				pw.startOffset = offset
+				pw.startTime = time.Now()
+				p.Send(multiStateMsg{index: idx, state: downloadRunning})
+				p.Send(multiProgressMsg{index: idx, downloaded: offset, total: total})
+				return pw
+			}
+
+			onRetry := func(attempt int, wait time.Duration, err error) {
+				note := fmt.Sprintf("retry %d in %s", attempt, wait.Round(time.Second))
+				if wait == 0 {
+					note = "trying next mirror"
+				}
+				p.Send(multiStateMsg{index: idx, state: downloadRetrying, note: note})
+			}
+
+			sum, err := newStreamChecksum(job.Checksum, job.ChecksumAlgo)
+			if err == nil {
+				err = fetchFile(ctx, job.URLs, job.DestPath, sum, onStart, onRetry)
+			}
+			if err != nil {
+				errs[idx] = err
+				p.Send(multiStateMsg{index: idx, state: downloadFailed, note: "failed"})
+				return
+			}
+			p.Send(multiStateMsg{index: idx, state: downloadDone})
+		}(idx, job)
+	}
+
+	wg.Wait()
+	p.Send(multiDoneMsg{})
+	<-uiDone
+
+	return errs
+}
@@ -0,0 +1,167 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMultiProgressModel(t *testing.T) {
+	jobs := []DownloadJob{
+		{Label: "Java 21", Size: 200 << 20},
+		{Label: "Java 17", Size: 100 << 20},
+		{Label: "Java 11 JRE", Size: 100 << 20},
+	}
+
+	var model tea.Model = newMultiProgressModel(jobs)
+	send := func(msg tea.Msg) string {
+		t.Helper()
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		if cmd != nil {
+			t.Errorf("Update(%T) returned a command", msg)
+		}
+		return model.View()
+	}
+
+	view := model.View()
+	if strings.Count(view, "waiting") != 3 || !strings.Contains(view, "0/3 complete") {
+		t.Errorf("initial View() =\n%s\nwant three waiting downloads", view)
+	}
+
+	send(multiStateMsg{index: 0, state: downloadRunning})
+	view = send(multiProgressMsg{index: 0, downloaded: 50 << 20, total: 200 << 20, speed: "10.0 MB/s"})
+	if !strings.Contains(view, "50.00 MB / 200.00 MB - 10.0 MB/s") {
+		t.Errorf("View() of a running download =\n%s\nwant its progress and speed", view)
+	}
+	if !strings.Contains(view, "50.00 MB / 400.00 MB (12%)") {
+		t.Errorf("View() =\n%s\nwant the overall progress of all downloads", view)
+	}
+
+	view = send(multiStateMsg{index: 1, state: downloadRetrying, note: "retry 1 in 2s"})
+	if !strings.Contains(view, "retry 1 in 2s") {
+		t.Errorf("View() of a retrying download =\n%s\nwant its retry note", view)
+	}
+
+	send(multiStateMsg{index: 0, state: downloadDone})
+	view = send(multiStateMsg{index: 2, state: downloadFailed, note: "failed"})
+	if !strings.Contains(view, "✓ 200.00 MB") || !strings.Contains(view, "✗ failed") {
+		t.Errorf("View() =\n%s\nwant one finished and one failed download", view)
+	}
+	// A finished download counts fully; a failed one counts as complete
+	if !strings.Contains(view, "200.00 MB / 400.00 MB (50%) - 2/3 complete") {
+		t.Errorf("View() =\n%s\nwant 2/3 complete at 50%%", view)
+	}
+
+	final, cmd := model.Update(multiDoneMsg{})
+	if m := final.(multiProgressModel); !m.quitting || m.cancelled || cmd == nil {
+		t.Errorf("Update(multiDoneMsg) quitting = %v, cancelled = %v; want to quit without cancelling", m.quitting, m.cancelled)
+	}
+	final, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if m := final.(multiProgressModel); !m.cancelled {
+		t.Error("Ctrl+C did not cancel the downloads")
+	}
+}
+
+func TestDownloadAll(t *testing.T) {
+	content, digest := testArchive()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		if strings.HasSuffix(r.URL.Path, "/missing.zip") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "jdk.zip", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var jobs []DownloadJob
+	for _, name := range []string{"jdk-25.zip", "jdk-21.zip", "missing.zip", "jdk-17.zip", "jdk-11.zip"} {
+		jobs = append(jobs, DownloadJob{
+			Label:        name,
+			URLs:         []string{server.URL + "/" + name},
+			DestPath:     filepath.Join(dir, name),
+			Size:         int64(len(content)),
+			Checksum:     digest,
+			ChecksumAlgo: ChecksumSHA256,
+		})
+	}
+
+	errs := DownloadAll(context.Background(), jobs)
+
+	for idx, job := range jobs {
+		if job.Label == "missing.zip" {
+			if errs[idx] == nil || !strings.Contains(errs[idx].Error(), "404") {
+				t.Errorf("%s error = %v, want not found", job.Label, errs[idx])
+			}
+			assertMissing(t, job.DestPath)
+			continue
+		}
+		if errs[idx] != nil {
+			t.Errorf("%s: %v", job.Label, errs[idx])
+			continue
+		}
+		assertFile(t, job.DestPath, content)
+	}
+	if maxRunning > maxConcurrentDownloads || maxRunning < 2 {
+		t.Errorf("%d downloads ran at once, want between 2 and %d", maxRunning, maxConcurrentDownloads)
+	}
+}
+
+func TestDownloadAllCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	stopped := errors.New("stopped by the caller")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(300*time.Millisecond, func() { cancel(stopped) })
+
+	dir := t.TempDir()
+	var jobs []DownloadJob
+	for _, name := range []string{"a.zip", "b.zip", "c.zip", "d.zip"} {
+		jobs = append(jobs, DownloadJob{Label: name, URLs: []string{server.URL + "/" + name}, DestPath: filepath.Join(dir, name)})
+	}
+
+	done := make(chan []error)
+	go func() { done <- DownloadAll(ctx, jobs) }()
+
+	select {
+	case errs := <-done:
+		for idx, err := range errs {
+			if err == nil {
+				t.Errorf("%s finished despite the cancellation", jobs[idx].Label)
+			}
+		}
+		// The job that never started reports the cause
+		if !errors.Is(errs[3], stopped) && !errors.Is(errs[3], context.Canceled) {
+			t.Errorf("waiting job error = %v, want the cancellation", errs[3])
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("DownloadAll() did not stop after the context was cancelled")
+	}
+}
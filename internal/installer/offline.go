@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"jv/internal/cache"
+)
+
+// cachedReleases lists the versions of a distributor that can be installed from the archive cache
+func cachedReleases(distributor Distributor) []JavaRelease {
+	c, err := cache.Open()
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var releases []JavaRelease
+	for _, e := range c.Entries() {
+		if e.Distributor != distributor.Name() || seen[e.Version] {
+			continue
+		}
+		if c.Lookup(e.Checksum) == nil {
+			continue
+		}
+		seen[e.Version] = true
+		releases = append(releases, JavaRelease{Version: e.Version})
+	}
+
+	// Sort descending by version
+	sort.Slice(releases, func(i, j int) bool {
+		a, errA := strconv.Atoi(releases[i].Version)
+		b, errB := strconv.Atoi(releases[j].Version)
+		if errA != nil || errB != nil {
+			return releases[i].Version > releases[j].Version
+		}
+		return a > b
+	})
+
+	return releases
+}
+
+// cachedDownloadInfo builds download information from the newest cached archive
+// for the given version, image type and architecture
+func cachedDownloadInfo(distributor Distributor, version string, imageType string, arch string) (*DownloadInfo, error) {
+	c, err := cache.Open()
+	if err != nil {
+		return nil, fmt.Errorf("archive cache unavailable: %w", err)
+	}
+
+	entry := c.Find(distributor.Name(), version, imageType, arch)
+	if entry == nil {
+		return nil, fmt.Errorf("no cached %s for Java %s on %s", ImageTypeLabel(imageType), version, ArchLabel(arch))
+	}
+
+	return &DownloadInfo{
+		URL:          entry.URL,
+		Checksum:     entry.Checksum,
+		ChecksumAlgo: entry.ChecksumAlgo,
+		Size:         entry.Size,
+		FileName:     entry.FileName,
+		ImageType:    entry.ImageType,
+		Arch:         entry.Arch,
+	}, nil
+}
@@ -0,0 +1,271 @@
+package installer
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"jv/internal/cache"
+	"jv/internal/config"
+	"jv/internal/theme"
+)
+
+// OpenJFXID is the config key of the OpenJFX SDK downloads, e.g. for mirror
+// rules under "distributors"
+const OpenJFXID = "openjfx"
+
+// OpenJFXVendor builds the OpenJFX SDKs. Installed SDKs record it as their
+// distributor and system-wide ones go into its Program Files folder.
+const OpenJFXVendor = "Gluon"
+
+const (
+	// openJFXMetadataURL lists every OpenJFX release published to Maven Central
+	openJFXMetadataURL = "https://repo1.maven.org/maven2/org/openjfx/javafx/maven-metadata.xml"
+
+	// openJFXDownloadBase serves the SDK archives, one folder per release
+	openJFXDownloadBase = "https://download2.gluonhq.com/openjfx"
+
+	// fxReleasesKey caches the last release listing for offline use
+	fxReleasesKey = "openjfx/releases"
+)
+
+// openJFXArchs maps architectures to the names in SDK file names
+var openJFXArchs = map[string]string{
+	ArchX64:     "x64",
+	ArchX86:     "x86",
+	ArchAArch64: "aarch64",
+}
+
+// openJFXMetadata is the part of maven-metadata.xml that lists releases
+type openJFXMetadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+// FXReleases returns the GA OpenJFX releases, newest first. When Maven
+// Central cannot be reached it falls back to the last listing.
+func FXReleases(ctx context.Context) ([]string, error) {
+	releases, err := fetchFXReleases(ctx)
+	if err == nil {
+		cache.StoreMetadata(fxReleasesKey, releases, cache.MetadataInfo{})
+		return releases, nil
+	}
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	var cached []string
+	if _, cacheErr := cache.LoadMetadata(fxReleasesKey, &cached); cacheErr == nil && len(cached) > 0 {
+		return cached, nil
+	}
+	return nil, fmt.Errorf("failed to list OpenJFX releases: %w", err)
+}
+
+func fetchFXReleases(ctx context.Context) ([]string, error) {
+	data, err := fetchSmall(ctx, openJFXMetadataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata openJFXMetadata
+	if err := xml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", openJFXMetadataURL, err)
+	}
+
+	// Early-access builds carry a suffix such as "-ea+3"
+	var releases []string
+	for _, version := range metadata.Versions {
+		if version != "" && !strings.Contains(version, "-") {
+			releases = append(releases, version)
+		}
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("no OpenJFX releases listed")
+	}
+
+	sort.SliceStable(releases, func(a, b int) bool {
+		return CompareReleases(releases[a], releases[b]) > 0
+	})
+	return releases, nil
+}
+
+// fxDownloadInfo describes the SDK archive of the newest OpenJFX release
+// matching version, a feature release such as "21" or a release such as
+// "21.0.5", adding its published checksum and the configured mirrors
+func (i *Installer) fxDownloadInfo(ctx context.Context, version string, arch string) (*DownloadInfo, error) {
+	fxArch, ok := openJFXArchs[arch]
+	if !ok {
+		return nil, fmt.Errorf("OpenJFX SDKs are not published for %s", ArchLabel(arch))
+	}
+
+	release := version
+	if MajorVersion(version) == version {
+		releases, err := FXReleases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		release = ""
+		for _, candidate := range releases {
+			if MajorVersion(candidate) == version {
+				release = candidate
+				break
+			}
+		}
+		if release == "" {
+			return nil, fmt.Errorf("no OpenJFX %s release found", version)
+		}
+	}
+
+	fileName := fmt.Sprintf("openjfx-%s_windows-%s_bin-sdk.zip", release, fxArch)
+	info := &DownloadInfo{
+		URL:       openJFXDownloadBase + "/" + release + "/" + fileName,
+		FileName:  fileName,
+		ImageType: ImageTypeFXSDK,
+		Arch:      arch,
+		Release:   release,
+	}
+
+	// Gluon publishes a .sha256 file next to every archive, so a missing one
+	// usually means there is no build for this release and architecture
+	info.mirrorRules = i.config.Distributor(OpenJFXID).Mirrors
+	checksum, algo, err := detachedChecksum(ctx, info.URL, fileName, info.mirrorRules)
+	switch {
+	case err == nil:
+		info.Checksum, info.ChecksumAlgo = checksum, algo
+	case ctx.Err() != nil:
+		return nil, context.Cause(ctx)
+	case !i.options.InsecureSkipChecksum:
+		return nil, fmt.Errorf("no OpenJFX %s SDK for Windows %s found: %w", release, ArchLabel(arch), err)
+	}
+
+	info.Mirrors = mirrorURLs(info.URL, info.mirrorRules)
+	return info, nil
+}
+
+// InstallFX installs the OpenJFX SDK matching version next to the JDKs of
+// the given scope and records it in installed_fx. Like a JDK install it is
+// verified, staged on the target volume and only then moved into place;
+// cancelling ctx rolls it back. It returns the receipt of the new SDK.
+func (i *Installer) InstallFX(ctx context.Context, version string, scope string) (receipt *config.InstalledFX, err error) {
+	fmt.Println()
+	fmt.Println(theme.Subtitle.Render(fmt.Sprintf("Installing OpenJFX SDK %s from %s", version, OpenJFXVendor)))
+	fmt.Println()
+
+	var info *DownloadInfo
+	var fetchErr error
+	spinnerErr := WithSpinner(ctx, "Fetching download information...", func(ctx context.Context) error {
+		info, fetchErr = i.fxDownloadInfo(ctx, version, i.arch())
+		return nil
+	})
+	if spinnerErr != nil {
+		return nil, spinnerErr
+	}
+	if fetchErr != nil {
+		return nil, fmt.Errorf("failed to get download URL: %w", fetchErr)
+	}
+
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Package:"), theme.ValueStyle.Render(info.FileName))
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Release:"), theme.ValueStyle.Render(info.Release))
+	fmt.Printf("%s %s\n", theme.LabelStyle.Render("Arch:   "), theme.ValueStyle.Render(ArchLabel(info.Arch)))
+	if len(info.Mirrors) > 0 {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Mirrors:"), theme.ValueStyle.Render(fmt.Sprintf("%d configured", len(info.Mirrors))))
+	}
+	fmt.Println()
+
+	tempDir, err := newWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	defer closeWorkspace(tempDir)
+
+	// Fetched, verified, staged and swapped in like a JDK archive
+	zipPath, err := fetchArchive(ctx, info, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyArchive(ctx, zipPath, info); err != nil {
+		return nil, err
+	}
+
+	isSystemWide := scope == "system" && i.isAdmin
+	if !isSystemWide {
+		scope = "user"
+	}
+	stage, err := stageArchive(ctx, zipPath, info, OpenJFXVendor, isSystemWide)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			stage.tx.Rollback()
+		}
+	}()
+
+	if _, statErr := os.Stat(filepath.Join(stage.extractedPath, "lib", "javafx.base.jar")); statErr != nil {
+		return nil, fmt.Errorf("invalid %s structure: lib\\javafx.base.jar not found", ImageTypeLabel(info.ImageType))
+	}
+	if info.Checksum != "" {
+		storeInCache(zipPath, info, MajorVersion(info.Release), OpenJFXVendor)
+	}
+
+	finalPath := stage.finalPath(info.Release)
+	if err := stage.commit(ctx, finalPath); err != nil {
+		return nil, err
+	}
+
+	receipt = &config.InstalledFX{
+		Version:      MajorVersion(info.Release),
+		Release:      info.Release,
+		Path:         finalPath,
+		Distributor:  OpenJFXVendor,
+		InstalledAt:  time.Now().Format(time.RFC3339),
+		Scope:        scope,
+		Checksum:     info.Checksum,
+		ChecksumAlgo: info.ChecksumAlgo,
+		Source:       info.URL,
+		Size:         DirSize(finalPath),
+		Arch:         info.Arch,
+	}
+	i.config.AddInstalledFX(*receipt)
+	if err := i.config.Save(); err != nil {
+		fmt.Printf("Warning: Failed to save config: %v\n", err)
+	}
+	return receipt, nil
+}
+
+// MatchingFX returns the newest installed OpenJFX SDK for the feature
+// release of version, preferring SDKs built for this machine, or nil
+func MatchingFX(cfg *config.Config, version string) *config.InstalledFX {
+	major := MajorVersion(version)
+
+	var best *config.InstalledFX
+	for idx := range cfg.InstalledFX {
+		fx := &cfg.InstalledFX[idx]
+		if fx.Version != major {
+			continue
+		}
+		if _, err := os.Stat(fx.Path); err != nil {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = fx
+		case (fx.Arch == HostArch()) != (best.Arch == HostArch()):
+			if fx.Arch == HostArch() {
+				best = fx
+			}
+		case CompareReleases(fx.Release, best.Release) > 0:
+			best = fx
+		}
+	}
+	return best
+}
+
+// FXLibPath returns the folder PATH_TO_FX points at for an installed SDK
+func FXLibPath(fx config.InstalledFX) string {
+	return filepath.Join(fx.Path, "lib")
+}
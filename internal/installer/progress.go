@@ -27,6 +27,12 @@ type progressErrMsg struct{ err error }
 
 type downloadCompleteMsg struct{}
 
+type progressRetryMsg struct {
+	attempt int
+	wait    time.Duration
+	err     error
+}
+
 // ProgressWriter wraps an io.Writer and tracks download progress
 type ProgressWriter struct {
 	total      int64
@@ -95,8 +101,10 @@ type ProgressModel struct {
 	totalBytes int64
 	downloaded int64
 	speed      string
+	retry      string
 	err        error
 	done       bool
+	cancelled  bool // Ctrl+C was pressed; DownloadFile stops the download
 }
 
 func NewProgressModel(totalBytes int64) ProgressModel {
@@ -123,6 +131,7 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
+			m.cancelled = true
 			return m, tea.Quit
 		}
 		return m, nil
@@ -135,6 +144,7 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update progress data
 		m.downloaded = msg.downloaded
 		m.speed = msg.speed
+		m.retry = ""
 
 		// Update progress bar
 		cmd := m.progress.SetPercent(msg.percent)
@@ -148,6 +158,11 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.done = true
 		return m, tea.Quit
 
+	case progressRetryMsg:
+		m.retry = fmt.Sprintf("Attempt %d failed: %v - retrying in %s",
+			msg.attempt, msg.err, msg.wait.Round(time.Second))
+		return m, nil
+
 	case progressErrMsg:
 		m.err = msg.err
 		return m, tea.Quit
@@ -187,17 +202,24 @@ func (m ProgressModel) View() string {
 	info := fmt.Sprintf("%s / %s (%.0f%%) - %s",
 		downloaded, total, percent, m.speed)
 
-	return "\n" +
+	view := "\n" +
 		pad + progressBar + "\n" +
 		pad + helpStyle(info) + "\n"
+
+	if m.retry != "" {
+		view += pad + helpStyle(m.retry) + "\n"
+	}
+
+	return view
 }
 
 // progressWriter is an io.Writer that sends progress updates to Bubble Tea
 type progressWriter struct {
-	total      int64
-	downloaded int64
-	startTime  time.Time
-	program    *tea.Program
+	total       int64
+	downloaded  int64
+	startOffset int64 // Bytes already on disk when the current attempt started
+	startTime   time.Time
+	program     *tea.Program
 }
 
 func newProgressWriter(total int64, program *tea.Program) *progressWriter {
@@ -209,6 +231,13 @@ func newProgressWriter(total int64, program *tea.Program) *progressWriter {
 	}
 }
 
+// resume resets the writer for a new attempt continuing from offset
+func (pw *progressWriter) resume(offset int64) {
+	pw.downloaded = offset
+	pw.startOffset = offset
+	pw.startTime = time.Now()
+}
+
 func (pw *progressWriter) Write(p []byte) (int, error) {
 	n := len(p)
 	pw.downloaded += int64(n)
@@ -230,7 +259,7 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 func (pw *progressWriter) GetSpeed() string {
 	elapsed := time.Since(pw.startTime).Seconds()
 	if elapsed > 0 {
-		speed := float64(pw.downloaded) / elapsed
+		speed := float64(pw.downloaded-pw.startOffset) / elapsed
 		if speed >= 1024*1024 {
 			return fmt.Sprintf("%.2f MB/s", speed/(1024*1024))
 		} else if speed >= 1024 {
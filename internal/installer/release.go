@@ -0,0 +1,143 @@
+package installer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"jv/internal/config"
+	"jv/internal/hooks"
+)
+
+// ReadReleaseFile parses the "release" file at the root of a JDK, which holds
+// KEY="value" lines such as JAVA_VERSION="21.0.5"
+func ReadReleaseFile(jdkPath string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(jdkPath, "release"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return values, scanner.Err()
+}
+
+// InstalledRelease returns the full release version of the JDK at jdkPath,
+// e.g. "21.0.5+11", or "" if the release file is missing
+func InstalledRelease(jdkPath string) string {
+	values, err := ReadReleaseFile(jdkPath)
+	if err != nil {
+		return ""
+	}
+
+	// JAVA_RUNTIME_VERSION carries the build number, JAVA_VERSION does not
+	if v := values["JAVA_RUNTIME_VERSION"]; v != "" {
+		return strings.TrimSuffix(v, "-LTS")
+	}
+	return values["JAVA_VERSION"]
+}
+
+var (
+	legacyReleasePattern = regexp.MustCompile(`^(?:1\.)?(\d+)(?:\.0)?[u_](\d+)(?:-b(\d+))?`)
+	// The optional pre-release part is that of early-access builds, e.g. "24-ea+20"
+	modernReleasePattern = regexp.MustCompile(`^(\d+(?:\.\d+)*)(?:-[A-Za-z0-9.]+)?(?:\+(\d+))?`)
+)
+
+// CompareReleases compares two release versions such as "21.0.5+11",
+// "8u432-b06" or "1.8.0_432". It returns -1, 0 or 1.
+func CompareReleases(a string, b string) int {
+	na := releaseNumbers(a)
+	nb := releaseNumbers(b)
+
+	for idx := 0; idx < len(na) || idx < len(nb); idx++ {
+		var x, y int
+		if idx < len(na) {
+			x = na[idx]
+		}
+		if idx < len(nb) {
+			y = nb[idx]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// releaseNumbers returns the comparable components of a release version:
+// feature, interim, update and patch numbers followed by the build number
+func releaseNumbers(release string) []int {
+	release = strings.TrimPrefix(strings.TrimSpace(release), "jdk")
+	release = strings.TrimPrefix(release, "-")
+
+	var version []string
+	var build string
+	if m := legacyReleasePattern.FindStringSubmatch(release); m != nil {
+		version = []string{m[1], "0", m[2]}
+		build = m[3]
+	} else if m := modernReleasePattern.FindStringSubmatch(release); m != nil {
+		version = strings.Split(m[1], ".")
+		build = m[2]
+	}
+
+	// Pad so that "21+35" compares as 21.0.0.0 build 35
+	numbers := make([]int, 5)
+	for idx := 0; idx < len(version) && idx < 4; idx++ {
+		numbers[idx], _ = strconv.Atoi(version[idx])
+	}
+	numbers[4], _ = strconv.Atoi(build)
+	return numbers
+}
+
+// MajorVersion returns the feature release number of a version string,
+// e.g. "21" for "21.0.5+11" and "8" for "1.8.0_432"
+func MajorVersion(version string) string {
+	numbers := releaseNumbers(version)
+	if numbers[0] == 0 {
+		return version
+	}
+	return strconv.Itoa(numbers[0])
+}
+
+// HookContext describes the JDK at jdkPath for hook commands, using its
+// install receipt when jv installed it and its release file otherwise.
+// version is used when neither names one.
+func HookContext(jdkPath string, version string) hooks.Context {
+	ctx := hooks.Context{Path: jdkPath, Version: version}
+
+	if cfg, err := config.Load(); err == nil {
+		if jdk := cfg.GetInstalledJDK(jdkPath); jdk != nil {
+			ctx.Version = jdk.Version
+			ctx.Release = jdk.Release
+			ctx.Vendor = jdk.Distributor
+			ctx.Scope = jdk.Scope
+			ctx.ImageType = jdk.ImageType
+			ctx.Arch = ArchLabel(jdk.Arch)
+			return ctx
+		}
+	}
+
+	if values, err := ReadReleaseFile(jdkPath); err == nil {
+		ctx.Release = InstalledRelease(jdkPath)
+		ctx.Vendor = values["IMPLEMENTOR"]
+		ctx.Arch = ArchLabel(JDKArch(jdkPath))
+		if ctx.Version == "" {
+			ctx.Version = MajorVersion(ctx.Release)
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,83 @@
+package installer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReleaseNumbers(t *testing.T) {
+	tests := []struct {
+		release string
+		want    []int
+	}{
+		{release: "21.0.5+11", want: []int{21, 0, 5, 0, 11}},
+		{release: "jdk-21.0.5+11", want: []int{21, 0, 5, 0, 11}},
+		{release: "21+35", want: []int{21, 0, 0, 0, 35}},
+		{release: "17.0.13", want: []int{17, 0, 13, 0, 0}},
+		{release: "11.0.25.1+9", want: []int{11, 0, 25, 1, 9}},
+		{release: "24-ea+20", want: []int{24, 0, 0, 0, 20}},
+		{release: "24+20-ea-beta", want: []int{24, 0, 0, 0, 20}},
+		{release: " 21.0.5+11 ", want: []int{21, 0, 5, 0, 11}},
+		{release: "8u432-b06", want: []int{8, 0, 432, 0, 6}},
+		{release: "jdk8u432-b06", want: []int{8, 0, 432, 0, 6}},
+		{release: "1.8.0_432", want: []int{8, 0, 432, 0, 0}},
+		{release: "1.8.0_432-b06", want: []int{8, 0, 432, 0, 6}},
+		{release: "", want: []int{0, 0, 0, 0, 0}},
+		{release: "latest", want: []int{0, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.release, func(t *testing.T) {
+			if got := releaseNumbers(tt.release); !slices.Equal(got, tt.want) {
+				t.Errorf("releaseNumbers(%q) = %v, want %v", tt.release, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "21.0.5+11", want: "21"},
+		{version: "jdk-17.0.13+11", want: "17"},
+		{version: "21", want: "21"},
+		{version: "8u432-b06", want: "8"},
+		{version: "1.8.0_432", want: "8"},
+		{version: "latest", want: "latest"},
+		{version: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := MajorVersion(tt.version); got != tt.want {
+				t.Errorf("MajorVersion(%q) = %q, want %q", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareReleases(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "newer update", a: "21.0.5+11", b: "21.0.4+7", want: 1},
+		{name: "same release", a: "21.0.5+11", b: "jdk-21.0.5+11", want: 0},
+		{name: "builds compare numerically", a: "21.0.5+9", b: "21.0.5+11", want: -1},
+		{name: "older feature release", a: "17.0.13+11", b: "21+35", want: -1},
+		{name: "patch number", a: "11.0.25.1+9", b: "11.0.25+9", want: 1},
+		{name: "legacy forms", a: "8u432-b06", b: "1.8.0_432-b06", want: 0},
+		{name: "legacy update", a: "8u442-b06", b: "8u432-b06", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareReleases(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareReleases(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
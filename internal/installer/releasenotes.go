@@ -0,0 +1,153 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"jv/internal/cache"
+	"jv/internal/theme"
+)
+
+// cvePattern matches CVE identifiers in release note titles
+var cvePattern = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// ReleaseNotes describes a release: when it was published, where its notes
+// are and which vulnerabilities it fixes
+type ReleaseNotes struct {
+	Release string          `json:"release"`           // Full release version, e.g. "21.0.5+11"
+	Date    time.Time       `json:"date"`              // Publication date; zero if unknown
+	URL     string          `json:"url,omitempty"`     // Human-readable release notes
+	CVEs    []string        `json:"cves,omitempty"`    // Vulnerabilities fixed, sorted
+	Changes []ReleaseChange `json:"changes,omitempty"` // Issues fixed or changed
+
+	// Unpublished is set when the distributor has not published the notes
+	// yet; such notes are not cached on disk
+	Unpublished bool `json:"-"`
+}
+
+// ReleaseChange is one issue listed in the release notes
+type ReleaseChange struct {
+	ID    string `json:"id"` // e.g. "JDK-8331579"
+	Title string `json:"title"`
+	Type  string `json:"type,omitempty"` // e.g. "Bug" or "Enhancement"
+	Link  string `json:"link,omitempty"`
+}
+
+// ReleaseNotesDistributor is implemented by distributors that publish release
+// notes
+type ReleaseNotesDistributor interface {
+	// GetReleaseNotes returns the notes of a full release such as "21.0.5+11",
+	// or of the latest GA release of a major version such as "21"
+	GetReleaseNotes(ctx context.Context, version string) (*ReleaseNotes, error)
+}
+
+// releaseNotesMemo remembers notes fetched during this run, by distributor and version
+var releaseNotesMemo sync.Map
+
+// FetchReleaseNotes returns the release notes of a version from its
+// distributor. Published notes of a full release never change and are cached
+// on disk; the latest release of a major version and notes that are not
+// published yet are looked up once per run.
+func FetchReleaseNotes(ctx context.Context, distributor Distributor, version string) (*ReleaseNotes, error) {
+	source, ok := distributor.(ReleaseNotesDistributor)
+	if !ok {
+		return nil, fmt.Errorf("%s does not publish release notes", distributor.Name())
+	}
+
+	memoKey := distributor.ID() + "|" + version
+	if notes, ok := releaseNotesMemo.Load(memoKey); ok {
+		return notes.(*ReleaseNotes), nil
+	}
+
+	isRelease := MajorVersion(version) != version
+	if isRelease {
+		var notes ReleaseNotes
+		if _, err := cache.LoadMetadata(releaseNotesKey(distributor, version), &notes); err == nil {
+			releaseNotesMemo.Store(memoKey, &notes)
+			return &notes, nil
+		}
+	}
+
+	notes, err := source.GetReleaseNotes(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	releaseNotesMemo.Store(memoKey, notes)
+	if notes.Release != "" {
+		releaseNotesMemo.Store(distributor.ID()+"|"+notes.Release, notes)
+		if !notes.Unpublished {
+			cache.StoreMetadata(releaseNotesKey(distributor, notes.Release), notes, cache.MetadataInfo{})
+		}
+	}
+	return notes, nil
+}
+
+func releaseNotesKey(distributor Distributor, release string) string {
+	return "release-notes/" + distributor.ID() + "/" + release
+}
+
+// ReleaseSummary is a one-line description of a release for menus and tables,
+// e.g. "21.0.5+11, released 2024-10-16, fixes 3 CVEs"
+func ReleaseSummary(notes *ReleaseNotes) string {
+	parts := []string{notes.Release}
+	if !notes.Date.IsZero() {
+		parts = append(parts, "released "+notes.Date.Format("2006-01-02"))
+	}
+	switch len(notes.CVEs) {
+	case 0:
+	case 1:
+		parts = append(parts, "fixes "+notes.CVEs[0])
+	default:
+		parts = append(parts, fmt.Sprintf("fixes %d CVEs", len(notes.CVEs)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// collectCVEs returns the CVE identifiers mentioned in texts, sorted and
+// without duplicates
+func collectCVEs(texts ...string) []string {
+	seen := make(map[string]bool)
+	var cves []string
+	for _, text := range texts {
+		for _, cve := range cvePattern.FindAllString(strings.ToUpper(text), -1) {
+			if !seen[cve] {
+				seen[cve] = true
+				cves = append(cves, cve)
+			}
+		}
+	}
+	sort.Strings(cves)
+	return cves
+}
+
+// ReleaseNotes returns the notes of a version from a distributor given by ID
+// or display name; the default distributor is Adoptium
+func (i *Installer) ReleaseNotes(ctx context.Context, name string, version string) (*ReleaseNotes, error) {
+	if name == "" {
+		name = AdoptiumID
+	}
+	distributor := i.distributorFor(name)
+	if distributor == nil {
+		return nil, fmt.Errorf("unknown distributor %q", name)
+	}
+	return FetchReleaseNotes(ctx, distributor, version)
+}
+
+// printReleaseNotes prints the date, fixed CVEs and notes link of a release
+// below the package details of an install
+func printReleaseNotes(notes *ReleaseNotes) {
+	if !notes.Date.IsZero() {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Date:   "), theme.ValueStyle.Render(notes.Date.Format("2006-01-02")))
+	}
+	if len(notes.CVEs) > 0 {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("CVEs:   "), theme.ValueStyle.Render(strings.Join(notes.CVEs, ", ")))
+	}
+	if notes.URL != "" {
+		fmt.Printf("%s %s\n", theme.LabelStyle.Render("Notes:  "), theme.PathStyle.Render(notes.URL))
+	}
+}
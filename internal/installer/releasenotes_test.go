@@ -0,0 +1,61 @@
+package installer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"jv/internal/config"
+)
+
+func TestFetchReleaseNotesUnpublished(t *testing.T) {
+	useTempCache(t)
+	t.Cleanup(releaseNotesMemo.Clear)
+
+	var published atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/assets/release_name/eclipse/"):
+			w.Write([]byte(`{"release_name":"jdk-21.0.9+10","timestamp":"2025-10-21T12:00:00Z"}`))
+		case strings.HasPrefix(r.URL.Path, "/assets/release_notes/") && published.Load():
+			w.Write([]byte(`{"release_notes":[{"id":"JDK-8350000","title":"Fix for CVE-2025-53057","type":"Bug"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	adoptium := NewAdoptiumDistributor(config.DistributorConfig{APIBaseURLs: []string{server.URL}})
+
+	notes, err := FetchReleaseNotes(context.Background(), adoptium, "21.0.9+10")
+	if err != nil {
+		t.Fatalf("FetchReleaseNotes() before the notes are published: %v", err)
+	}
+	if !notes.Unpublished || len(notes.CVEs) != 0 {
+		t.Fatalf("FetchReleaseNotes() = %+v, want unpublished notes", notes)
+	}
+
+	// A later run sees the notes once they are published
+	releaseNotesMemo.Clear()
+	published.Store(true)
+	notes, err = FetchReleaseNotes(context.Background(), adoptium, "21.0.9+10")
+	if err != nil {
+		t.Fatalf("FetchReleaseNotes() after the notes are published: %v", err)
+	}
+	if notes.Unpublished || strings.Join(notes.CVEs, ",") != "CVE-2025-53057" {
+		t.Errorf("FetchReleaseNotes() = %+v, want the published notes fixing CVE-2025-53057", notes)
+	}
+
+	// Published notes are served from the disk cache
+	releaseNotesMemo.Clear()
+	server.Close()
+	notes, err = FetchReleaseNotes(context.Background(), adoptium, "21.0.9+10")
+	if err != nil {
+		t.Fatalf("FetchReleaseNotes() from the cache: %v", err)
+	}
+	if strings.Join(notes.CVEs, ",") != "CVE-2025-53057" {
+		t.Errorf("cached notes fix %q, want CVE-2025-53057", notes.CVEs)
+	}
+}
@@ -0,0 +1,266 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"jv/internal/config"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// Results recorded in the signature field of installed_jdks
+const (
+	SignatureVerified   = "verified"   // Signed by a trusted key
+	SignatureUnsigned   = "unsigned"   // No signature is published for the archive
+	SignatureUnverified = "unverified" // Signed, but no trusted key could be loaded
+	SignatureOff        = "off"        // Checking is disabled by policy
+)
+
+// defaultKeyserver serves keys that are configured by fingerprint only
+const defaultKeyserver = "https://keyserver.ubuntu.com"
+
+// ErrBadSignature means an archive's signature does not match any trusted key
+var ErrBadSignature = errors.New("signature verification failed")
+
+// errNoSignature means no detached signature is published for an archive
+var errNoSignature = errors.New("no signature published")
+
+// vendorKeys are the pinned signing keys of each distributor, by display name
+var vendorKeys = map[string][]config.SigningKey{
+	"Eclipse Adoptium": {
+		{Name: "Eclipse Adoptium", Fingerprint: "3B04D753C9050D9A5D343F39843C48A565F8F04B"},
+	},
+}
+
+// detachedSignatureSuffixes are tried, in order, next to an archive without a
+// published signature link
+var detachedSignatureSuffixes = []string{".sig", ".asc"}
+
+// SignatureResult is the outcome of checking an archive's OpenPGP signature
+type SignatureResult struct {
+	Status   string // SignatureVerified, SignatureUnsigned, SignatureUnverified or SignatureOff
+	SignedBy string // Fingerprint of the signing key when verified
+	Reason   string // Why the signature could not be verified
+}
+
+// VerifySignature checks the detached OpenPGP signature of an archive against
+// the pinned keys of its distributor and the keys in the config. A signature
+// that does not verify is always an error; a missing signature or key is an
+// error only under the "required" policy.
+func VerifySignature(ctx context.Context, archivePath string, downloadInfo *DownloadInfo, distributor string) (SignatureResult, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return SignatureResult{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	settings := cfg.Signatures
+
+	policy := strings.ToLower(settings.Policy)
+	switch policy {
+	case "":
+		policy = config.SignaturePolicyOptional
+	case config.SignaturePolicyOff:
+		return SignatureResult{Status: SignatureOff}, nil
+	case config.SignaturePolicyOptional, config.SignaturePolicyRequired:
+	default:
+		return SignatureResult{}, fmt.Errorf("unknown signature policy %q (use off, optional or required)", settings.Policy)
+	}
+
+	result, err := checkSignature(ctx, archivePath, downloadInfo, distributor, settings)
+	if err != nil {
+		return result, err
+	}
+	if result.Status != SignatureVerified && policy == config.SignaturePolicyRequired {
+		return result, fmt.Errorf("signature required by policy: %s", result.Reason)
+	}
+	return result, nil
+}
+
+// checkSignature does the work of VerifySignature without applying the
+// policy. It only fails for a signature that does not verify.
+func checkSignature(ctx context.Context, archivePath string, downloadInfo *DownloadInfo, distributor string, settings config.SignatureConfig) (SignatureResult, error) {
+	signature, err := loadSignature(ctx, archivePath, downloadInfo)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return SignatureResult{}, context.Cause(ctx)
+	case errors.Is(err, errNoSignature):
+		return SignatureResult{Status: SignatureUnsigned, Reason: err.Error()}, nil
+	default:
+		// The signature may exist but could not be fetched, so the archive
+		// is not known to be unsigned
+		return SignatureResult{Status: SignatureUnverified, Reason: fmt.Sprintf("failed to fetch signature: %v", err)}, nil
+	}
+
+	keys := append(append([]config.SigningKey{}, vendorKeys[distributor]...), settings.Keys...)
+	if len(keys) == 0 {
+		return SignatureResult{Status: SignatureUnverified, Reason: "no trusted signing keys are configured"}, nil
+	}
+
+	var keyring openpgp.EntityList
+	var keyErrs []string
+	for _, key := range keys {
+		entity, err := loadSigningKey(ctx, key, settings.Keyserver)
+		if err != nil {
+			keyErrs = append(keyErrs, err.Error())
+			continue
+		}
+		keyring = append(keyring, entity)
+	}
+	if len(keyring) == 0 {
+		return SignatureResult{Status: SignatureUnverified, Reason: strings.Join(keyErrs, "; ")}, nil
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return SignatureResult{Status: SignatureUnverified, Reason: err.Error()}, nil
+	}
+	defer archive.Close()
+
+	var signer *openpgp.Entity
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(keyring, archive, bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(keyring, archive, bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return SignatureResult{}, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+
+	return SignatureResult{
+		Status:   SignatureVerified,
+		SignedBy: strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint[:])),
+	}, nil
+}
+
+// loadSignature returns the detached signature for an archive: the published
+// signature link, a .sig or .asc file next to the download URL, or one next
+// to a local archive. Published signatures are fetched through the download
+// mirrors. Only when every candidate is missing does the error wrap
+// errNoSignature; network and server errors are returned as they are.
+func loadSignature(ctx context.Context, archivePath string, downloadInfo *DownloadInfo) ([]byte, error) {
+	if downloadInfo.SignatureURL != "" {
+		data, err := fetchSmallMirrored(ctx, downloadInfo.SignatureURL, downloadInfo.mirrorRules)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %v", errNoSignature, err)
+		}
+		return data, err
+	}
+
+	if downloadInfo.URL != "" {
+		var fetchErr error
+		for _, suffix := range detachedSignatureSuffixes {
+			data, err := fetchSmallMirrored(ctx, downloadInfo.URL+suffix, downloadInfo.mirrorRules)
+			if err == nil {
+				return data, nil
+			}
+			if !isNotFound(err) && fetchErr == nil {
+				fetchErr = err
+			}
+		}
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, fmt.Errorf("%w for %s", errNoSignature, downloadInfo.FileName)
+	}
+
+	for _, suffix := range detachedSignatureSuffixes {
+		data, err := os.ReadFile(archivePath + suffix)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: no %s.sig or %s.asc next to the archive", errNoSignature, filepath.Base(archivePath), filepath.Base(archivePath))
+}
+
+// loadSigningKey reads a trusted key from its file, the local key store or the
+// keyserver, and checks that it has the pinned fingerprint. Keys fetched from
+// the keyserver are stored for later runs.
+func loadSigningKey(ctx context.Context, key config.SigningKey, keyserver string) (*openpgp.Entity, error) {
+	fingerprint := normalizeFingerprint(key.Fingerprint)
+	if len(fingerprint) != 40 {
+		return nil, fmt.Errorf("invalid fingerprint %q for key %s", key.Fingerprint, key.Name)
+	}
+
+	stored := filepath.Join(config.Dir(), "keys", fingerprint+".asc")
+	var data []byte
+	var err error
+	fetched := false
+
+	switch {
+	case key.File != "":
+		data, err = os.ReadFile(key.File)
+	default:
+		data, err = os.ReadFile(stored)
+		if err != nil {
+			if keyserver == "" {
+				keyserver = defaultKeyserver
+			}
+			data, err = fetchSmall(ctx, strings.TrimRight(keyserver, "/")+"/pks/lookup?op=get&options=mr&search=0x"+url.QueryEscape(fingerprint))
+			fetched = err == nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load key %s: %w", fingerprint, err)
+	}
+
+	entity, err := findKey(data, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if fetched {
+		if err := os.MkdirAll(filepath.Dir(stored), 0755); err == nil {
+			os.WriteFile(stored, data, 0644)
+		}
+	}
+	return entity, nil
+}
+
+// findKey parses an armored or binary keyring and returns the key whose
+// primary fingerprint is fingerprint
+func findKey(data []byte, fingerprint string) (*openpgp.Entity, error) {
+	var keyring openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key %s: %w", fingerprint, err)
+	}
+
+	for _, entity := range keyring {
+		if strings.EqualFold(hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]), fingerprint) {
+			return entity, nil
+		}
+	}
+	return nil, fmt.Errorf("key file does not contain the pinned key %s", fingerprint)
+}
+
+// normalizeFingerprint strips spaces and a 0x prefix and upper-cases a fingerprint
+func normalizeFingerprint(fingerprint string) string {
+	fingerprint = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(fingerprint)), "0x")
+	return strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+}
+
+// FormatFingerprint groups a fingerprint in blocks of four for display
+func FormatFingerprint(fingerprint string) string {
+	var groups []string
+	for len(fingerprint) > 4 {
+		groups = append(groups, fingerprint[:4])
+		fingerprint = fingerprint[4:]
+	}
+	return strings.Join(append(groups, fingerprint), " ")
+}
@@ -0,0 +1,150 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// smokeTimeout bounds each command of the smoke test; a cold start under a
+// virus scanner can take a while, a hung launcher must not stall the install
+const smokeTimeout = time.Minute
+
+// Results recorded in the smoke_test field of installed_jdks
+const (
+	SmokePassed      = "passed"       // java -version and a javac compile-and-run succeeded
+	SmokeVersionOnly = "version-only" // java -version succeeded; the image has no javac
+	SmokeSkipped     = "skipped"      // The image has no launcher to run
+)
+
+// smokeClass is compiled and run to prove javac and java work together
+const smokeClass = `public class JvSmokeTest {
+    public static void main(String[] args) {
+        System.out.println("jv-smoke-ok " + System.getProperty("java.version"));
+    }
+}
+`
+
+var (
+	smokeVersionPattern = regexp.MustCompile(`version\s+"([^"]+)"`)
+	smokeBuildPattern   = regexp.MustCompile(`\(build ([^)]+)\)`)
+)
+
+// SmokeResult is the outcome of running a freshly installed JDK
+type SmokeResult struct {
+	Status      string // SmokePassed, SmokeVersionOnly or SmokeSkipped
+	FullVersion string // Version reported by java -version, e.g. "21.0.5"
+	Build       string // Runtime build, e.g. "21.0.5+11-LTS"
+}
+
+// SmokeTest runs java -version in the JDK at jdkPath and checks the reported
+// version against the requested major version and, when known, the full
+// release. Images with javac also compile and run a small class. workDir is
+// used for the generated class and must be writable.
+func SmokeTest(ctx context.Context, jdkPath string, imageType string, version string, release string, workDir string) (SmokeResult, error) {
+	java := jdkTool(jdkPath, "java")
+	if !IsRunnableImage(imageType) || java == "" {
+		return SmokeResult{Status: SmokeSkipped}, nil
+	}
+
+	output, err := runSmokeCommand(ctx, java, "-version")
+	if err != nil {
+		return SmokeResult{}, fmt.Errorf("java -version failed: %w", err)
+	}
+
+	result := SmokeResult{Status: SmokeVersionOnly}
+	if m := smokeVersionPattern.FindStringSubmatch(output); m != nil {
+		result.FullVersion = m[1]
+	}
+	if m := smokeBuildPattern.FindStringSubmatch(output); m != nil {
+		result.Build = m[1]
+	}
+	if result.FullVersion == "" {
+		return result, fmt.Errorf("java -version did not report a version:\n%s", strings.TrimSpace(output))
+	}
+
+	if major := MajorVersion(result.FullVersion); major != MajorVersion(version) {
+		return result, fmt.Errorf("installed Java reports version %s, expected Java %s", result.FullVersion, version)
+	}
+	reported := result.Build
+	if reported == "" {
+		reported = result.FullVersion
+	}
+	if release != "" && !sameRelease(reported, release) {
+		return result, fmt.Errorf("installed Java reports build %s, expected %s", reported, release)
+	}
+
+	javac := jdkTool(jdkPath, "javac")
+	if javac == "" {
+		return result, nil
+	}
+
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return result, fmt.Errorf("failed to prepare smoke test: %w", err)
+	}
+	source := filepath.Join(workDir, "JvSmokeTest.java")
+	if err := os.WriteFile(source, []byte(smokeClass), 0644); err != nil {
+		return result, fmt.Errorf("failed to prepare smoke test: %w", err)
+	}
+
+	if output, err := runSmokeCommand(ctx, javac, "-d", workDir, source); err != nil {
+		return result, fmt.Errorf("javac failed: %w\n%s", err, strings.TrimSpace(output))
+	}
+	output, err = runSmokeCommand(ctx, java, "-cp", workDir, "JvSmokeTest")
+	if err != nil {
+		return result, fmt.Errorf("running a compiled class failed: %w\n%s", err, strings.TrimSpace(output))
+	}
+	if !strings.Contains(output, "jv-smoke-ok") {
+		return result, fmt.Errorf("compiled class produced unexpected output:\n%s", strings.TrimSpace(output))
+	}
+
+	result.Status = SmokePassed
+	return result, nil
+}
+
+// runSmokeCommand runs a JDK tool with the smoke test timeout and returns its
+// combined output
+func runSmokeCommand(ctx context.Context, name string, args ...string) (string, error) {
+	runCtx, cancel := context.WithTimeout(ctx, smokeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(runCtx, name, args...).CombinedOutput()
+	if ctx.Err() != nil {
+		return string(output), context.Cause(ctx)
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return string(output), fmt.Errorf("%s timed out after %s", filepath.Base(name), smokeTimeout)
+	}
+	return string(output), err
+}
+
+// jdkTool returns the path of a launcher in the JDK's bin directory, or "" if
+// it is missing. Tarballs built for Linux or macOS ship tools without .exe.
+func jdkTool(jdkPath string, name string) string {
+	for _, file := range []string{name + ".exe", name} {
+		path := filepath.Join(jdkPath, "bin", file)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// sameRelease reports whether two release versions name the same version and,
+// when both carry one, the same build. Suffixes such as "-LTS" are ignored.
+func sameRelease(a string, b string) bool {
+	na := releaseNumbers(a)
+	nb := releaseNumbers(b)
+	for idx := 0; idx < 4; idx++ {
+		if na[idx] != nb[idx] {
+			return false
+		}
+	}
+	return na[4] == 0 || nb[4] == 0 || na[4] == nb[4]
+}
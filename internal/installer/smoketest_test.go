@@ -0,0 +1,28 @@
+package installer
+
+import "testing"
+
+func TestSameRelease(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "identical", a: "21.0.5+11", b: "21.0.5+11", want: true},
+		{name: "prefixed", a: "jdk-21.0.5+11", b: "21.0.5+11", want: true},
+		{name: "build unknown", a: "21.0.5", b: "21.0.5+11", want: true},
+		{name: "other build", a: "21.0.5+11", b: "21.0.5+12", want: false},
+		{name: "other update", a: "21.0.5+11", b: "21.0.4+11", want: false},
+		{name: "other patch", a: "11.0.25.1+9", b: "11.0.25+9", want: false},
+		{name: "legacy forms", a: "8u432-b06", b: "1.8.0_432", want: true},
+		{name: "legacy build", a: "8u432-b06", b: "1.8.0_432-b07", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameRelease(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameRelease(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
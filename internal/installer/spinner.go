@@ -1,6 +1,8 @@
 package installer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,15 +11,19 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// ErrCancelled is returned when the user presses Ctrl+C during an operation
+var ErrCancelled = errors.New("cancelled by user")
+
 type spinnerFinishedMsg struct {
 	err error
 }
 
 type spinnerModel struct {
-	spinner  spinner.Model
-	message  string
-	quitting bool
-	err      error
+	spinner   spinner.Model
+	message   string
+	quitting  bool
+	cancelled bool
+	err       error
 }
 
 func newSpinnerModel(message string) spinnerModel {
@@ -40,6 +46,7 @@ func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
 			m.quitting = true
+			m.cancelled = true
 			return m, tea.Quit
 		}
 		return m, nil
@@ -66,21 +73,39 @@ func (m spinnerModel) View() string {
 	return fmt.Sprintf("\n %s %s\n\n", m.spinner.View(), m.message)
 }
 
-// WithSpinner runs a function with a spinner animation
-func WithSpinner(message string, fn func() error) error {
+// WithSpinner runs a function with a spinner animation. If the user presses
+// Ctrl+C the context passed to fn is cancelled and WithSpinner waits for fn to
+// return, so nothing is left running, and returns ErrCancelled. Otherwise it
+// returns the cause of ctx if that was cancelled, or the error of fn. Without
+// a terminal for the animation fn simply runs to the end.
+func WithSpinner(ctx context.Context, message string, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
 	p := tea.NewProgram(newSpinnerModel(message))
+	done := make(chan struct{})
+	var fnErr error
 
 	// Run function in background
 	go func() {
+		defer close(done)
 		time.Sleep(100 * time.Millisecond) // Give UI time to start
-		err := fn()
-		p.Send(spinnerFinishedMsg{err: err})
+		fnErr = fn(ctx)
+		p.Send(spinnerFinishedMsg{err: fnErr})
 	}()
 
 	// Run the spinner UI
-	if _, err := p.Run(); err != nil {
-		return err
+	final, err := p.Run()
+	m, _ := final.(spinnerModel)
+	if m.cancelled || errors.Is(err, tea.ErrInterrupted) {
+		cancel(ErrCancelled)
+		<-done
+		return ErrCancelled
 	}
 
-	return nil
+	<-done
+	if cause := context.Cause(ctx); cause != nil {
+		return cause
+	}
+	return fnErr
 }
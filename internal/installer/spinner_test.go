@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSpinnerModelUpdate(t *testing.T) {
+	failed := errors.New("download failed")
+
+	tests := []struct {
+		name          string
+		msg           tea.Msg
+		wantQuit      bool
+		wantCancelled bool
+		wantErr       error
+	}{
+		{name: "ctrl+c", msg: tea.KeyMsg{Type: tea.KeyCtrlC}, wantQuit: true, wantCancelled: true},
+		{name: "other key", msg: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}},
+		{name: "finished", msg: spinnerFinishedMsg{}, wantQuit: true},
+		{name: "failed", msg: spinnerFinishedMsg{err: failed}, wantQuit: true, wantErr: failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, cmd := newSpinnerModel("Working...").Update(tt.msg)
+			m := model.(spinnerModel)
+
+			quit := cmd != nil && isQuit(cmd())
+			if quit != tt.wantQuit || m.quitting != tt.wantQuit {
+				t.Errorf("Update() quits = %v (quitting %v), want %v", quit, m.quitting, tt.wantQuit)
+			}
+			if m.cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %v, want %v", m.cancelled, tt.wantCancelled)
+			}
+			if m.err != tt.wantErr {
+				t.Errorf("err = %v, want %v", m.err, tt.wantErr)
+			}
+			if tt.wantQuit && m.View() != "" {
+				t.Errorf("View() after quitting = %q, want nothing", m.View())
+			}
+		})
+	}
+}
+
+func isQuit(msg tea.Msg) bool {
+	_, ok := msg.(tea.QuitMsg)
+	return ok
+}
+
+func TestWithSpinner(t *testing.T) {
+	failed := errors.New("download failed")
+
+	if err := WithSpinner(context.Background(), "Working...", func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("WithSpinner() of a successful function: %v", err)
+	}
+	if err := WithSpinner(context.Background(), "Working...", func(ctx context.Context) error { return failed }); err != failed {
+		t.Errorf("WithSpinner() error = %v, want %v", err, failed)
+	}
+}
+
+func TestWithSpinnerCancelled(t *testing.T) {
+	stopped := errors.New("stopped by the caller")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(200*time.Millisecond, func() { cancel(stopped) })
+
+	returned := false
+	err := WithSpinner(ctx, "Working...", func(ctx context.Context) error {
+		<-ctx.Done()
+		returned = true
+		return ctx.Err()
+	})
+	if !errors.Is(err, stopped) {
+		t.Errorf("WithSpinner() error = %v, want the cause %v", err, stopped)
+	}
+	// The function has returned before WithSpinner does
+	if !returned {
+		t.Error("WithSpinner() returned while the function was still running")
+	}
+}
@@ -0,0 +1,101 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Archive formats understood by ExtractArchive
+const (
+	formatZip   = "zip"
+	formatTarGz = "tar.gz"
+)
+
+// archiveFormat picks the extractor for an archive from its file name,
+// falling back to the leading magic bytes for names without an extension
+func archiveFormat(archivePath string, fileName string) (string, error) {
+	name := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return formatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGz, nil
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return formatZip, nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return formatTarGz, nil
+	}
+	return "", fmt.Errorf("unsupported archive format for %s", fileName)
+}
+
+// ExtractTarGz extracts a gzip-compressed tarball into destDir, which is
+// emptied first, and returns the path of the archive's single top-level
+// directory. Executable bits, symlinks and hard links are preserved.
+func ExtractTarGz(ctx context.Context, archivePath string, destDir string) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tar.gz: %w", err)
+	}
+	defer gz.Close()
+
+	x, err := newExtraction(destDir)
+	if err != nil {
+		return "", err
+	}
+
+	tr := tar.NewReader(contextReader{ctx: ctx, r: gz})
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read tar.gz: %w", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(hdr.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.file(hdr.Name, hdr.FileInfo().Mode(), tr)
+		case tar.TypeSymlink:
+			err = x.link(hdr.Name, hdr.Linkname, false)
+		case tar.TypeLink:
+			err = x.link(hdr.Name, hdr.Linkname, true)
+		case tar.TypeXGlobalHeader:
+			// pax metadata, not a file
+		default:
+			err = fmt.Errorf("unsupported entry %s in archive (type %q)", hdr.Name, hdr.Typeflag)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return x.finish()
+}
@@ -0,0 +1,173 @@
+package installer
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"jv/internal/env"
+	"jv/internal/lockfile"
+)
+
+// Prefix and suffix of the work directories a transaction creates next to
+// the installations, so leftovers from a crash can be recognised
+const (
+	stagingPrefix = ".jv-staging-"
+	backupSuffix  = ".jv-backup"
+)
+
+// tarGzExpansion estimates how much larger a JDK tarball gets when extracted;
+// unlike zip, the uncompressed size is unknown without reading the stream
+const tarGzExpansion = 3
+
+// installTransaction stages a new installation on the same volume as its
+// final directory and swaps it in with renames, so the previous installation
+// survives any failure until the new one is in place. Ctrl+C cancels the
+// install's context, which makes InstallArchive roll the transaction back.
+type installTransaction struct {
+	mu         sync.Mutex
+	stagingDir string
+	finalPath  string
+	backupPath string
+	swapped    bool
+	done       bool
+}
+
+// beginInstall creates a staging directory inside installBase
+func beginInstall(installBase string) (*installTransaction, error) {
+	stagingDir, err := os.MkdirTemp(installBase, stagingPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	if err := lockfile.WriteOwner(filepath.Join(stagingDir, ownerFile)); err != nil {
+		os.RemoveAll(stagingDir)
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &installTransaction{stagingDir: stagingDir}, nil
+}
+
+// Commit moves extractedPath to finalPath. An existing installation at
+// finalPath is renamed aside first and restored if the move fails.
+func (t *installTransaction) Commit(extractedPath string, finalPath string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.finalPath = finalPath
+	if _, err := os.Stat(finalPath); err == nil {
+		t.backupPath = finalPath + backupSuffix
+		if err := os.RemoveAll(t.backupPath); err != nil {
+			return fmt.Errorf("failed to remove stale backup: %w", err)
+		}
+		fmt.Printf("Replacing existing installation at %s\n", finalPath)
+		if err := os.Rename(finalPath, t.backupPath); err != nil {
+			t.backupPath = ""
+			// The install base was just written to, so access denied means
+			// something inside is in use
+			if env.IsFileLocked(err, true) {
+				return fmt.Errorf("the existing installation at %s is in use; close running Java programs and try again", finalPath)
+			}
+			return fmt.Errorf("failed to move existing installation aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(extractedPath, finalPath); err != nil {
+		if t.backupPath != "" {
+			os.Rename(t.backupPath, finalPath)
+			t.backupPath = ""
+		}
+		return fmt.Errorf("failed to move %s to its final location: %w", filepath.Base(extractedPath), err)
+	}
+
+	t.swapped = true
+	return nil
+}
+
+// Finish makes the transaction permanent by deleting the replaced
+// installation and the staging directory
+func (t *installTransaction) Finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return
+	}
+	t.done = true
+
+	if t.backupPath != "" {
+		if err := os.RemoveAll(t.backupPath); err != nil {
+			fmt.Printf("Warning: failed to remove previous installation at %s: %v\n", t.backupPath, err)
+		}
+	}
+	os.RemoveAll(t.stagingDir)
+}
+
+// Rollback undoes a transaction that has not finished: the new installation
+// is removed and the previous one, if any, is put back. It returns false if
+// the transaction had already finished.
+func (t *installTransaction) Rollback() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return false
+	}
+	t.done = true
+
+	if t.swapped {
+		os.RemoveAll(t.finalPath)
+		if t.backupPath != "" {
+			if err := os.Rename(t.backupPath, t.finalPath); err != nil {
+				fmt.Printf("Warning: failed to restore previous installation from %s: %v\n", t.backupPath, err)
+			}
+		}
+	}
+	os.RemoveAll(t.stagingDir)
+	return true
+}
+
+// ensureFreeSpace fails if the volume holding dir has less than needed bytes
+// available. An unknown amount of free space does not block the install.
+func ensureFreeSpace(dir string, needed int64) error {
+	if needed <= 0 {
+		return nil
+	}
+
+	free, err := env.DiskFree(dir)
+	if err != nil {
+		return nil
+	}
+
+	// Leave some headroom for the filesystem and other writers
+	needed += needed / 10
+	if uint64(needed) > free {
+		return fmt.Errorf("not enough disk space in %s: %s needed, %s available",
+			dir, FormatSize(needed), FormatSize(int64(free)))
+	}
+	return nil
+}
+
+// extractedSize returns the space an archive needs once extracted: exact for
+// zip files, estimated for tarballs
+func extractedSize(archivePath string, fileName string) int64 {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return 0
+	}
+
+	if format, _ := archiveFormat(archivePath, fileName); format == formatZip {
+		if reader, err := zip.OpenReader(archivePath); err == nil {
+			defer reader.Close()
+			var total int64
+			for _, file := range reader.File {
+				total += int64(file.UncompressedSize64)
+			}
+			return total
+		}
+	}
+
+	return info.Size() * tarGzExpansion
+}
@@ -0,0 +1,227 @@
+package installer
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRelease creates dir with a release file holding content, standing in
+// for an installation
+func writeRelease(t *testing.T, dir string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "release"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRelease returns the content of the release file in dir, or "" if there is none
+func readRelease(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "release"))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func assertMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", filepath.Base(path), err)
+	}
+}
+
+// stageInstall begins a transaction in a temp install base and extracts a
+// new installation holding content into its staging directory
+func stageInstall(t *testing.T, content string) (tx *installTransaction, extracted string, finalPath string) {
+	t.Helper()
+	base := t.TempDir()
+	tx, err := beginInstall(base)
+	if err != nil {
+		t.Fatalf("beginInstall(): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tx.stagingDir, ownerFile)); err != nil {
+		t.Errorf("staging directory has no owner file: %v", err)
+	}
+
+	extracted = filepath.Join(tx.stagingDir, "jdk-21.0.5+11")
+	writeRelease(t, extracted, content)
+	return tx, extracted, filepath.Join(base, "jdk-21")
+}
+
+func TestInstallTransactionFreshInstall(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+
+	if err := tx.Commit(extracted, finalPath); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+	if got := readRelease(t, finalPath); got != "new" {
+		t.Errorf("installation holds %q after Commit(), want %q", got, "new")
+	}
+	assertMissing(t, finalPath+backupSuffix)
+
+	tx.Finish()
+	assertMissing(t, tx.stagingDir)
+	if got := readRelease(t, finalPath); got != "new" {
+		t.Errorf("installation holds %q after Finish(), want %q", got, "new")
+	}
+	if tx.Rollback() {
+		t.Error("Rollback() after Finish() reported a rollback")
+	}
+	if got := readRelease(t, finalPath); got != "new" {
+		t.Errorf("Rollback() after Finish() changed the installation to %q", got)
+	}
+}
+
+func TestInstallTransactionReplace(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+	writeRelease(t, finalPath, "old")
+
+	if err := tx.Commit(extracted, finalPath); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+	if got := readRelease(t, finalPath); got != "new" {
+		t.Errorf("installation holds %q after Commit(), want %q", got, "new")
+	}
+	// The previous installation is kept aside until the install finishes
+	if got := readRelease(t, finalPath+backupSuffix); got != "old" {
+		t.Errorf("backup holds %q after Commit(), want %q", got, "old")
+	}
+
+	tx.Finish()
+	assertMissing(t, finalPath+backupSuffix)
+	assertMissing(t, tx.stagingDir)
+	if got := readRelease(t, finalPath); got != "new" {
+		t.Errorf("installation holds %q after Finish(), want %q", got, "new")
+	}
+}
+
+func TestInstallTransactionReplacesStaleBackup(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+	writeRelease(t, finalPath, "old")
+	writeRelease(t, finalPath+backupSuffix, "stale")
+
+	if err := tx.Commit(extracted, finalPath); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+	if got := readRelease(t, finalPath+backupSuffix); got != "old" {
+		t.Errorf("backup holds %q after Commit(), want %q", got, "old")
+	}
+	tx.Finish()
+}
+
+func TestInstallTransactionRestoresOnFailedMove(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+	writeRelease(t, finalPath, "old")
+
+	// A missing source makes the move into place fail after the existing
+	// installation was renamed aside
+	missing := extracted + "-missing"
+	err := tx.Commit(missing, finalPath)
+	if err == nil || !strings.Contains(err.Error(), "failed to move") {
+		t.Fatalf("Commit() of a missing directory error = %v, want a failed move", err)
+	}
+	if got := readRelease(t, finalPath); got != "old" {
+		t.Errorf("installation holds %q after a failed Commit(), want the previous %q", got, "old")
+	}
+	assertMissing(t, finalPath+backupSuffix)
+
+	if !tx.Rollback() {
+		t.Error("Rollback() after a failed Commit() reported no rollback")
+	}
+	if got := readRelease(t, finalPath); got != "old" {
+		t.Errorf("Rollback() after a failed Commit() left %q, want %q", got, "old")
+	}
+	assertMissing(t, tx.stagingDir)
+}
+
+func TestInstallTransactionRollbackAfterSwap(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+	writeRelease(t, finalPath, "old")
+
+	if err := tx.Commit(extracted, finalPath); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+	if !tx.Rollback() {
+		t.Fatal("Rollback() reported no rollback")
+	}
+
+	if got := readRelease(t, finalPath); got != "old" {
+		t.Errorf("installation holds %q after Rollback(), want the previous %q", got, "old")
+	}
+	assertMissing(t, finalPath+backupSuffix)
+	assertMissing(t, tx.stagingDir)
+
+	if tx.Rollback() {
+		t.Error("second Rollback() reported a rollback")
+	}
+	tx.Finish()
+	if got := readRelease(t, finalPath); got != "old" {
+		t.Errorf("Finish() after Rollback() changed the installation to %q", got)
+	}
+}
+
+func TestInstallTransactionRollbackFreshInstall(t *testing.T) {
+	tx, extracted, finalPath := stageInstall(t, "new")
+
+	if err := tx.Commit(extracted, finalPath); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+	if !tx.Rollback() {
+		t.Fatal("Rollback() reported no rollback")
+	}
+	assertMissing(t, finalPath)
+	assertMissing(t, tx.stagingDir)
+}
+
+func TestInstallTransactionRollbackBeforeCommit(t *testing.T) {
+	tx, _, finalPath := stageInstall(t, "new")
+	writeRelease(t, finalPath, "old")
+
+	if !tx.Rollback() {
+		t.Fatal("Rollback() reported no rollback")
+	}
+	if got := readRelease(t, finalPath); got != "old" {
+		t.Errorf("Rollback() before Commit() changed the installation to %q", got)
+	}
+	assertMissing(t, tx.stagingDir)
+}
+
+func TestEnsureFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		needed  int64
+		wantErr bool
+	}{
+		{name: "nothing needed", needed: 0},
+		{name: "unknown size", needed: -1},
+		{name: "small archive", needed: 1 << 10},
+		{name: "more than any disk", needed: math.MaxInt64 / 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ensureFreeSpace(dir, tt.needed)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not enough disk space in "+dir) {
+					t.Fatalf("ensureFreeSpace(%d) error = %v, want not enough disk space", tt.needed, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ensureFreeSpace(%d): %v", tt.needed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"jv/internal/httpclient"
+)
+
+const (
+	// apiTimeout bounds small metadata requests to distributor APIs
+	apiTimeout = 30 * time.Second
+
+	// readTimeout is the longest a download may go without receiving data
+	readTimeout = 60 * time.Second
+
+	// maxDownloadAttempts is the number of tries before a download gives up
+	maxDownloadAttempts = 5
+
+	// initialRetryBackoff is the wait before the first retry, doubled after each failure
+	initialRetryBackoff = 2 * time.Second
+
+	// partSuffix marks partially downloaded files that can be resumed
+	partSuffix = ".part"
+
+	// maxSmallFileSize bounds checksum, signature and key files, which are tiny
+	maxSmallFileSize = 1 << 20
+)
+
+// errNotFound means a fetched URL answered 404 Not Found
+var errNotFound = errors.New("not found")
+
+// fetchSmall downloads a small file such as a detached checksum or signature
+func fetchSmall(ctx context.Context, fileURL string) ([]byte, error) {
+	return fetchLimited(ctx, fileURL, maxSmallFileSize)
+}
+
+// fetchLimited downloads a metadata file, reading at most limit bytes. Paths
+// and file:// URLs, e.g. on a file share, are read from disk.
+func fetchLimited(ctx context.Context, fileURL string, limit int64) ([]byte, error) {
+	if filePath, ok := localSourcePath(fileURL); ok {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, limit))
+	}
+
+	client, err := httpclient.Client(apiTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s returned status %d: %w", fileURL, resp.StatusCode, errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", fileURL, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// isNotFound reports whether a fetch failed because the file does not exist,
+// as opposed to a network or server error
+func isNotFound(err error) bool {
+	return errors.Is(err, errNotFound) || errors.Is(err, os.ErrNotExist)
+}
+
+// localSourcePath returns the file path a location refers to when it is not an
+// HTTP URL: a file:// URL, a drive path or a UNC path on a file share
+func localSourcePath(location string) (string, bool) {
+	if location == "" {
+		return "", false
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return location, true
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return "", false
+	case "file":
+		if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
+			return `\\` + u.Host + filepath.FromSlash(u.Path), true
+		}
+		// file:///C:/jdk.zip has the path /C:/jdk.zip
+		p := u.Path
+		if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+			p = p[1:]
+		}
+		return filepath.FromSlash(p), true
+	}
+	return location, true
+}
+
+// retryableError marks a failure that may succeed when attempted again
+type retryableError struct {
+	err        error
+	retryAfter time.Duration // Server-requested wait, zero if none
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+func retryable(err error, retryAfter time.Duration) error {
+	return &retryableError{err: err, retryAfter: retryAfter}
+}
+
+// isRetryable reports whether err was marked as retryable and the wait the server asked for
+func isRetryable(err error) (bool, time.Duration) {
+	var rerr *retryableError
+	if errors.As(err, &rerr) {
+		return true, rerr.retryAfter
+	}
+	return false, 0
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusRequestTimeout ||
+		status >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// parseContentRange parses a "bytes start-end/total" header
+func parseContentRange(value string) (start int64, total int64, ok bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "bytes ") {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(strings.TrimPrefix(value, "bytes "), "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if totalPart != "*" {
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+// retryDelay returns the wait before the given retry attempt (1-based)
+func retryDelay(attempt int, serverWait time.Duration) time.Duration {
+	wait := initialRetryBackoff << (attempt - 1)
+	if serverWait > wait {
+		wait = serverWait
+	}
+	return wait
+}
+
+// stallReader cancels a request when no data arrives within timeout
+type stallReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	fired   atomic.Bool
+	err     error // Last read error other than io.EOF
+}
+
+func newStallReader(r io.Reader, timeout time.Duration, cancel context.CancelFunc) *stallReader {
+	s := &stallReader{r: r, timeout: timeout}
+	s.timer = time.AfterFunc(timeout, func() {
+		s.fired.Store(true)
+		cancel()
+	})
+	return s
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.timer.Reset(s.timeout)
+	}
+	if err != nil && err != io.EOF {
+		s.err = err
+		if s.fired.Load() {
+			s.err = fmt.Errorf("no data received for %s", s.timeout)
+		}
+	}
+	return n, err
+}
+
+// Stop releases the stall timer
+func (s *stallReader) Stop() {
+	s.timer.Stop()
+}
+
+// contextReader fails reads once ctx is cancelled, so long copies such as
+// extracting a large file stop promptly
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if c.ctx.Err() != nil {
+		return 0, context.Cause(c.ctx)
+	}
+	return c.r.Read(p)
+}
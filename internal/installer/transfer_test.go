@@ -0,0 +1,68 @@
+package installer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantStart int64
+		wantTotal int64
+		wantOK    bool
+	}{
+		{name: "range with total", value: "bytes 100-199/1000", wantStart: 100, wantTotal: 1000, wantOK: true},
+		{name: "from the start", value: "bytes 0-999/1000", wantStart: 0, wantTotal: 1000, wantOK: true},
+		{name: "unknown total", value: "bytes 500-999/*", wantStart: 500, wantTotal: -1, wantOK: true},
+		{name: "surrounding space", value: "  bytes 10-19/20 ", wantStart: 10, wantTotal: 20, wantOK: true},
+		{name: "empty", value: ""},
+		{name: "other unit", value: "items 0-9/10"},
+		{name: "unsatisfied range", value: "bytes */1000"},
+		{name: "no total", value: "bytes 0-99"},
+		{name: "no end", value: "bytes 100/1000"},
+		{name: "bad start", value: "bytes x-99/100"},
+		{name: "bad total", value: "bytes 0-99/lots"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, total, ok := parseContentRange(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseContentRange(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && (start != tt.wantStart || total != tt.wantTotal) {
+				t.Errorf("parseContentRange(%q) = %d, %d, want %d, %d", tt.value, start, total, tt.wantStart, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "120", min: 120 * time.Second, max: 120 * time.Second},
+		{name: "seconds with space", value: " 5 ", min: 5 * time.Second, max: 5 * time.Second},
+		{name: "zero seconds", value: "0"},
+		{name: "negative seconds", value: "-30"},
+		{name: "garbage", value: "soon"},
+		{name: "future date", value: time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat), min: 85 * time.Second, max: 90 * time.Second},
+		{name: "past date", value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrPartialRemoval means the installation was moved out of place but some of
+// its files could not be deleted
+var ErrPartialRemoval = errors.New("installation removed but some files were left behind")
+
+// RemoveInstallation deletes an installed JDK directory. The directory is
+// first renamed so a JDK with files still in use is left intact instead of
+// half deleted; Windows refuses the rename while any file inside is open.
+func RemoveInstallation(path string) error {
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+
+	trash := path + ".uninstalling"
+	if err := os.RemoveAll(trash); err != nil {
+		return fmt.Errorf("failed to clean up previous uninstall: %w", err)
+	}
+	if err := os.Rename(path, trash); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(trash); err != nil {
+		return fmt.Errorf("%w: delete %s manually: %v", ErrPartialRemoval, trash, err)
+	}
+	return nil
+}
+
+// DirSize returns the total size of the regular files under path
+func DirSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
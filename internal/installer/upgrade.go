@@ -0,0 +1,137 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"jv/internal/config"
+)
+
+// ErrNotUpgradable is reported for JDKs installed from a local archive or URL,
+// which have no distributor to ask for newer releases
+var ErrNotUpgradable = errors.New("not installed from a known distributor")
+
+// UpdateCheck is the result of comparing an installed JDK with the newest
+// release of the same major version and image type
+type UpdateCheck struct {
+	JDK     config.InstalledJDK
+	Current string        // Installed release, "" if it could not be determined
+	Latest  *DownloadInfo // Newest release offered by the distributor
+	Notes   *ReleaseNotes // Notes of the newest release when it is an update
+	Err     error
+}
+
+// Outdated reports whether a newer release than the installed one exists
+func (c UpdateCheck) Outdated() bool {
+	if c.Err != nil || c.Latest == nil || c.Latest.Release == "" || c.Current == "" {
+		return false
+	}
+	return CompareReleases(c.Latest.Release, c.Current) > 0
+}
+
+// CheckUpdates asks each JDK's distributor for the latest release of the same
+// major version and image type. Checks run concurrently.
+func (i *Installer) CheckUpdates(ctx context.Context, jdks []config.InstalledJDK) []UpdateCheck {
+	checks := make([]UpdateCheck, len(jdks))
+	var wg sync.WaitGroup
+
+	for idx, jdk := range jdks {
+		checks[idx] = UpdateCheck{JDK: jdk, Current: jdk.Release}
+		if checks[idx].Current == "" {
+			checks[idx].Current = InstalledRelease(jdk.Path)
+		}
+
+		distributor := i.distributorFor(jdk.Distributor)
+		if distributor == nil {
+			checks[idx].Err = ErrNotUpgradable
+			continue
+		}
+
+		wg.Add(1)
+		go func(check *UpdateCheck, distributor Distributor) {
+			defer wg.Done()
+			imageType := check.JDK.ImageType
+			if imageType == "" {
+				imageType = ImageTypeJDK
+			}
+			// Early-access installs only move to newer early-access builds
+			check.Latest, check.Err = i.resolveDownloadInfo(ctx, distributor, MajorVersion(check.JDK.Version), installedArch(check.JDK), imageType, check.JDK.EarlyAccess)
+			if check.Outdated() && !check.JDK.EarlyAccess {
+				check.Notes, _ = FetchReleaseNotes(ctx, distributor, check.Latest.Release)
+			}
+		}(&checks[idx], distributor)
+	}
+
+	wg.Wait()
+	return checks
+}
+
+// Upgrade installs the newer release found by check next to the outdated JDK,
+// in the same scope, and records it in the config. The outdated JDK is left in
+// place. It returns the new installation.
+func (i *Installer) Upgrade(ctx context.Context, check UpdateCheck) (*config.InstalledJDK, error) {
+	if !check.Outdated() {
+		return nil, fmt.Errorf("no newer release of Java %s is available", check.JDK.Version)
+	}
+
+	isSystemWide := check.JDK.Scope == "system"
+	if isSystemWide && !i.isAdmin {
+		return nil, fmt.Errorf("upgrading a system-wide installation requires administrator privileges")
+	}
+
+	distributor := i.distributorFor(check.JDK.Distributor)
+	if distributor == nil {
+		return nil, fmt.Errorf("unknown distributor %q", check.JDK.Distributor)
+	}
+
+	version := MajorVersion(check.JDK.Version)
+	upgraded, err := InstallJDK(ctx, check.Latest, version, distributor.Name(), isSystemWide)
+	if err != nil {
+		return nil, fmt.Errorf("installation failed: %w", err)
+	}
+	upgraded.Version = check.JDK.Version
+	upgraded.Scope = check.JDK.Scope
+	upgraded.ImageType = check.JDK.ImageType
+
+	// Reload in case the config changed since the installer was created,
+	// e.g. a superseded build removed after an earlier upgrade
+	if cfg, err := config.Load(); err == nil {
+		i.config = cfg
+	}
+	if !isSystemWide && IsRunnableImage(upgraded.ImageType) {
+		i.config.AddCustomPath(upgraded.Path)
+	}
+	i.config.AddInstalledJDK(*upgraded)
+	if err := i.config.Save(); err != nil {
+		return upgraded, fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return upgraded, nil
+}
+
+// installedArch returns the architecture of an installed JDK, so an upgrade
+// keeps it. Installations recorded before jv tracked the architecture were
+// made for this machine.
+func installedArch(jdk config.InstalledJDK) string {
+	if jdk.Arch != "" {
+		return jdk.Arch
+	}
+	if arch := JDKArch(jdk.Path); arch != "" {
+		return arch
+	}
+	return HostArch()
+}
+
+// distributorFor returns the distributor recorded for an installation, which
+// is stored by display name in installed_jdks
+func (i *Installer) distributorFor(name string) Distributor {
+	for _, d := range i.distributors {
+		if strings.EqualFold(d.ID(), name) || strings.EqualFold(d.Name(), name) {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"jv/internal/config"
+)
+
+func TestCheckUpdates(t *testing.T) {
+	useTempCache(t)
+	t.Cleanup(releaseNotesMemo.Clear)
+
+	unpinned := filepath.Join(t.TempDir(), "jdk-21")
+	if err := os.MkdirAll(unpinned, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(unpinned, "release"), []byte("JAVA_VERSION=\"21.0.4\"\nJAVA_RUNTIME_VERSION=\"21.0.4+7-LTS\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		jdk          config.InstalledJDK
+		wantCurrent  string
+		wantLatest   string
+		wantOutdated bool
+		wantNotes    bool
+		wantErr      string
+	}{
+		{
+			name:        "outdated",
+			jdk:         config.InstalledJDK{Version: "21", Release: "21.0.4+7", Distributor: "Corp JDK", Arch: ArchX64},
+			wantCurrent: "21.0.4+7", wantLatest: "21.0.5+11", wantOutdated: true, wantNotes: true,
+		},
+		{
+			name:        "up to date",
+			jdk:         config.InstalledJDK{Version: "21", Release: "21.0.5+11", Distributor: "corp", Arch: ArchX64},
+			wantCurrent: "21.0.5+11", wantLatest: "21.0.5+11",
+		},
+		{
+			name:        "release read from the installation",
+			jdk:         config.InstalledJDK{Version: "21", Path: unpinned, Distributor: "Corp JDK", Arch: ArchX64},
+			wantCurrent: "21.0.4+7", wantLatest: "21.0.5+11", wantOutdated: true, wantNotes: true,
+		},
+		{
+			name:        "same image type",
+			jdk:         config.InstalledJDK{Version: "21", Release: "21.0.4+7", Distributor: "Corp JDK", Arch: ArchX64, ImageType: ImageTypeJRE},
+			wantCurrent: "21.0.4+7", wantLatest: "21.0.5+11", wantOutdated: true, wantNotes: true,
+		},
+		{
+			name:        "same architecture",
+			jdk:         config.InstalledJDK{Version: "21", Release: "21.0.5+11", Distributor: "Corp JDK", Arch: ArchAArch64},
+			wantCurrent: "21.0.5+11", wantLatest: "21.0.5.1+1", wantOutdated: true,
+		},
+		{
+			name:        "early access stays early access",
+			jdk:         config.InstalledJDK{Version: "24", Release: "24-ea+19", Distributor: "Corp JDK", Arch: ArchX64, EarlyAccess: true},
+			wantCurrent: "24-ea+19", wantLatest: "24-ea+20", wantOutdated: true,
+		},
+		{
+			name:    "no release of the version",
+			jdk:     config.InstalledJDK{Version: "11", Release: "11.0.25+9", Distributor: "Corp JDK", Arch: ArchX64},
+			wantErr: "no JDK found for Java 11",
+		},
+		{
+			name:    "installed from an archive",
+			jdk:     config.InstalledJDK{Version: "21", Release: "21.0.4+7", Distributor: CustomDistributor},
+			wantErr: ErrNotUpgradable.Error(),
+		},
+	}
+
+	index := newTestIndex(t, "index.yaml", testIndex)
+	inst := &Installer{config: &config.Config{}, distributors: map[int]Distributor{1: index}}
+
+	jdks := make([]config.InstalledJDK, len(tests))
+	for idx, tt := range tests {
+		jdks[idx] = tt.jdk
+	}
+	checks := inst.CheckUpdates(context.Background(), jdks)
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checks[idx]
+			if tt.wantErr != "" {
+				if check.Err == nil || !strings.Contains(check.Err.Error(), tt.wantErr) {
+					t.Fatalf("check error = %v, want %q", check.Err, tt.wantErr)
+				}
+				if check.Outdated() {
+					t.Error("Outdated() = true for a failed check")
+				}
+				return
+			}
+			if check.Err != nil {
+				t.Fatalf("check error: %v", check.Err)
+			}
+			if check.Current != tt.wantCurrent || check.Latest.Release != tt.wantLatest {
+				t.Errorf("check = %s -> %s, want %s -> %s", check.Current, check.Latest.Release, tt.wantCurrent, tt.wantLatest)
+			}
+			if check.Outdated() != tt.wantOutdated {
+				t.Errorf("Outdated() = %v, want %v", check.Outdated(), tt.wantOutdated)
+			}
+			if check.Latest.EarlyAccess != tt.jdk.EarlyAccess || check.Latest.Arch != tt.jdk.Arch {
+				t.Errorf("latest is %s, early access %v; want %s, early access %v", check.Latest.Arch, check.Latest.EarlyAccess, tt.jdk.Arch, tt.jdk.EarlyAccess)
+			}
+			if (check.Notes != nil) != tt.wantNotes {
+				t.Errorf("Notes = %+v, want notes %v", check.Notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestCheckUpdatesWithoutChecksum(t *testing.T) {
+	useTempCache(t)
+	t.Cleanup(releaseNotesMemo.Clear)
+
+	index := newTestIndex(t, "index.yaml", "releases:\n  - release: 21.0.5+11\n    packages:\n      - url: jdk-21.0.5.zip\n")
+	jdk := config.InstalledJDK{Version: "21", Release: "21.0.4+7", Distributor: "Corp JDK", Arch: ArchX64}
+
+	// jv upgrade uses the same options as jv outdated
+	tests := []struct {
+		name         string
+		options      Options
+		wantOutdated bool
+	}{
+		{name: "refused", options: Options{}},
+		{name: "insecure skip", options: Options{InsecureSkipChecksum: true}, wantOutdated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &Installer{config: &config.Config{}, options: tt.options, distributors: map[int]Distributor{1: index}}
+			check := inst.CheckUpdates(context.Background(), []config.InstalledJDK{jdk})[0]
+			if check.Outdated() != tt.wantOutdated {
+				t.Errorf("Outdated() = %v (error %v), want %v", check.Outdated(), check.Err, tt.wantOutdated)
+			}
+			if !tt.wantOutdated && !errors.Is(check.Err, ErrNoChecksum) {
+				t.Errorf("check error = %v, want ErrNoChecksum", check.Err)
+			}
+		})
+	}
+}
+
+func TestUpgradeRequiresNewerRelease(t *testing.T) {
+	inst := &Installer{config: &config.Config{}}
+	check := UpdateCheck{
+		JDK:     config.InstalledJDK{Version: "21", Release: "21.0.5+11", Distributor: "Corp JDK"},
+		Current: "21.0.5+11",
+		Latest:  &DownloadInfo{Release: "21.0.5+11"},
+	}
+	if _, err := inst.Upgrade(context.Background(), check); err == nil || !strings.Contains(err.Error(), "no newer release of Java 21") {
+		t.Errorf("Upgrade() of an up-to-date JDK error = %v, want no newer release", err)
+	}
+
+	check.Latest = &DownloadInfo{Release: "21.0.6+7"}
+	check.JDK.Scope = "system"
+	if _, err := inst.Upgrade(context.Background(), check); err == nil || !strings.Contains(err.Error(), "administrator") {
+		t.Errorf("Upgrade() of a system-wide JDK without admin rights error = %v, want administrator required", err)
+	}
+}
@@ -0,0 +1,112 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"jv/internal/cache"
+)
+
+// StaleVersionsError is returned with the last successful version listing of
+// a distributor when listing its versions failed
+type StaleVersionsError struct {
+	Err      error
+	StoredAt time.Time // When the cached listing was fetched
+}
+
+func (e *StaleVersionsError) Error() string {
+	return fmt.Sprintf("%v; showing versions %s", e.Err, CachedAge(e.StoredAt))
+}
+
+func (e *StaleVersionsError) Unwrap() error {
+	return e.Err
+}
+
+// revalidatingDistributor is implemented by distributors whose version listing
+// supports HTTP conditional requests. fetchVersions sends etag as
+// If-None-Match and returns nil releases when the listing has not changed.
+type revalidatingDistributor interface {
+	fetchVersions(ctx context.Context, etag string) ([]JavaRelease, cache.MetadataInfo, error)
+}
+
+// listVersions returns the GA versions a distributor offers. Each successful
+// listing is cached; it is reused without asking while the server's
+// Cache-Control allows, and returned with a *StaleVersionsError when the
+// distributor cannot be reached.
+func listVersions(ctx context.Context, distributor Distributor) ([]JavaRelease, error) {
+	key := "versions/" + distributor.ID()
+	var cached []JavaRelease
+	info, err := cache.LoadMetadata(key, &cached)
+	hasCache := err == nil && len(cached) > 0
+	if hasCache && info.Fresh() {
+		return cached, nil
+	}
+
+	var releases []JavaRelease
+	var validators cache.MetadataInfo
+	if source, ok := distributor.(revalidatingDistributor); ok {
+		etag := ""
+		if hasCache {
+			etag = info.ETag
+		}
+		releases, validators, err = source.fetchVersions(ctx, etag)
+		if err == nil && releases == nil && hasCache {
+			releases = cached
+		}
+	} else {
+		releases, err = distributor.GetAvailableVersions(ctx)
+	}
+
+	if err == nil && len(releases) > 0 {
+		cache.StoreMetadata(key, releases, validators)
+		return releases, nil
+	}
+	if !hasCache {
+		return releases, err
+	}
+	if err == nil {
+		err = fmt.Errorf("%s listed no versions", distributor.Name())
+	}
+	return cached, &StaleVersionsError{Err: err, StoredAt: info.StoredAt}
+}
+
+// cacheValidators reads the ETag and Cache-Control max-age of a response.
+// no-store and no-cache responses are revalidated on every use.
+func cacheValidators(resp *http.Response) cache.MetadataInfo {
+	info := cache.MetadataInfo{ETag: resp.Header.Get("ETag")}
+
+	maxAge := -1
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache":
+			return info
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge = seconds
+			}
+		}
+	}
+	if maxAge > 0 {
+		info.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+	return info
+}
+
+// CachedAge describes how long ago a cached listing was fetched, e.g.
+// "cached 3 days ago"
+func CachedAge(storedAt time.Time) string {
+	days := int(time.Since(storedAt).Hours() / 24)
+	switch {
+	case days <= 0:
+		return "cached today"
+	case days == 1:
+		return "cached 1 day ago"
+	default:
+		return fmt.Sprintf("cached %d days ago", days)
+	}
+}
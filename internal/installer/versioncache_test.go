@@ -0,0 +1,330 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jv/internal/cache"
+	"jv/internal/config"
+)
+
+func TestCacheValidators(t *testing.T) {
+	tests := []struct {
+		name         string
+		etag         string
+		cacheControl string
+		wantMaxAge   time.Duration // Zero for no expiry
+	}{
+		{name: "no headers"},
+		{name: "ETag only", etag: `"abc"`},
+		{name: "max-age", etag: `W/"abc"`, cacheControl: "max-age=600", wantMaxAge: 10 * time.Minute},
+		{name: "with other directives", cacheControl: "public, max-age=3600, must-revalidate", wantMaxAge: time.Hour},
+		{name: "upper case", cacheControl: "Public, Max-Age=60", wantMaxAge: time.Minute},
+		{name: "quoted", cacheControl: `max-age="120"`, wantMaxAge: 2 * time.Minute},
+		{name: "zero max-age", cacheControl: "max-age=0"},
+		{name: "invalid max-age", cacheControl: "max-age=soon"},
+		{name: "no-cache wins", etag: `"abc"`, cacheControl: "max-age=600, no-cache"},
+		{name: "no-store wins", cacheControl: "no-store, max-age=600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: make(http.Header)}
+			if tt.etag != "" {
+				resp.Header.Set("ETag", tt.etag)
+			}
+			if tt.cacheControl != "" {
+				resp.Header.Set("Cache-Control", tt.cacheControl)
+			}
+
+			before := time.Now()
+			info := cacheValidators(resp)
+
+			if info.ETag != tt.etag {
+				t.Errorf("ETag = %q, want %q", info.ETag, tt.etag)
+			}
+			if tt.wantMaxAge == 0 {
+				if !info.Expires.IsZero() {
+					t.Errorf("Expires = %v, want none", info.Expires)
+				}
+				return
+			}
+			if info.Expires.Before(before.Add(tt.wantMaxAge)) || info.Expires.After(time.Now().Add(tt.wantMaxAge)) {
+				t.Errorf("Expires = %v, want %s from now", info.Expires, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+// fakeDistributor lists versions from a script of answers, one per call
+type fakeDistributor struct {
+	answers []fakeAnswer
+	etags   []string // ETag passed to each fetchVersions call
+}
+
+type fakeAnswer struct {
+	releases   []JavaRelease
+	validators cache.MetadataInfo
+	err        error
+}
+
+func (d *fakeDistributor) ID() string   { return "fake" }
+func (d *fakeDistributor) Name() string { return "Fake" }
+
+func (d *fakeDistributor) GetAvailableVersions(ctx context.Context) ([]JavaRelease, error) {
+	releases, _, err := d.fetchVersions(ctx, "")
+	return releases, err
+}
+
+func (d *fakeDistributor) GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d *fakeDistributor) fetchVersions(ctx context.Context, etag string) ([]JavaRelease, cache.MetadataInfo, error) {
+	d.etags = append(d.etags, etag)
+	if len(d.etags) > len(d.answers) {
+		return nil, cache.MetadataInfo{}, errors.New("unexpected request")
+	}
+	answer := d.answers[len(d.etags)-1]
+	return answer.releases, answer.validators, answer.err
+}
+
+// plainDistributor hides fetchVersions, like a distributor without
+// conditional requests
+type plainDistributor struct {
+	d *fakeDistributor
+}
+
+func (p plainDistributor) ID() string   { return p.d.ID() }
+func (p plainDistributor) Name() string { return p.d.Name() }
+func (p plainDistributor) GetAvailableVersions(ctx context.Context) ([]JavaRelease, error) {
+	return p.d.GetAvailableVersions(ctx)
+}
+func (p plainDistributor) GetDownloadURL(ctx context.Context, version string, arch string, imageType string) (*DownloadInfo, error) {
+	return p.d.GetDownloadURL(ctx, version, arch, imageType)
+}
+
+// useTempCache points the archive and metadata cache at a temp directory
+func useTempCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+}
+
+// storeVersions caches a listing of the fake distributor
+func storeVersions(t *testing.T, releases []JavaRelease, info cache.MetadataInfo) {
+	t.Helper()
+	if err := cache.StoreMetadata("versions/fake", releases, info); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func versionList(releases []JavaRelease) string {
+	var versions []string
+	for _, r := range releases {
+		versions = append(versions, r.Version)
+	}
+	return strings.Join(versions, ",")
+}
+
+var (
+	oldListing = []JavaRelease{{Version: "21", IsLTS: true}, {Version: "17", IsLTS: true}}
+	newListing = []JavaRelease{{Version: "25", IsLTS: true}, {Version: "21", IsLTS: true}, {Version: "17", IsLTS: true}}
+)
+
+func TestListVersionsWithoutCache(t *testing.T) {
+	useTempCache(t)
+	d := &fakeDistributor{answers: []fakeAnswer{{releases: newListing, validators: cache.MetadataInfo{ETag: `"v2"`}}}}
+
+	releases, err := listVersions(context.Background(), d)
+	if err != nil {
+		t.Fatalf("listVersions(): %v", err)
+	}
+	if got := versionList(releases); got != "25,21,17" {
+		t.Errorf("listVersions() = %s, want 25,21,17", got)
+	}
+	if len(d.etags) != 1 || d.etags[0] != "" {
+		t.Errorf("fetchVersions() ETags = %q, want one unconditional request", d.etags)
+	}
+
+	var stored []JavaRelease
+	info, err := cache.LoadMetadata("versions/fake", &stored)
+	if err != nil {
+		t.Fatalf("listing not cached: %v", err)
+	}
+	if versionList(stored) != "25,21,17" || info.ETag != `"v2"` {
+		t.Errorf("cached listing = %s with ETag %q, want 25,21,17 with \"v2\"", versionList(stored), info.ETag)
+	}
+}
+
+func TestListVersionsFreshCache(t *testing.T) {
+	useTempCache(t)
+	storeVersions(t, oldListing, cache.MetadataInfo{ETag: `"v1"`, Expires: time.Now().Add(time.Hour)})
+	d := &fakeDistributor{}
+
+	releases, err := listVersions(context.Background(), d)
+	if err != nil {
+		t.Fatalf("listVersions(): %v", err)
+	}
+	if got := versionList(releases); got != "21,17" {
+		t.Errorf("listVersions() = %s, want the cached 21,17", got)
+	}
+	if len(d.etags) != 0 {
+		t.Errorf("fetchVersions() called %d times for a fresh listing", len(d.etags))
+	}
+}
+
+func TestListVersionsNotModified(t *testing.T) {
+	useTempCache(t)
+	storedAt := time.Now().Add(-48 * time.Hour)
+	storeVersions(t, oldListing, cache.MetadataInfo{StoredAt: storedAt, ETag: `"v1"`, Expires: time.Now().Add(-time.Hour)})
+	d := &fakeDistributor{answers: []fakeAnswer{{validators: cache.MetadataInfo{ETag: `"v1"`, Expires: time.Now().Add(time.Hour)}}}}
+
+	releases, err := listVersions(context.Background(), d)
+	if err != nil {
+		t.Fatalf("listVersions(): %v", err)
+	}
+	if got := versionList(releases); got != "21,17" {
+		t.Errorf("listVersions() = %s, want the cached 21,17", got)
+	}
+	if len(d.etags) != 1 || d.etags[0] != `"v1"` {
+		t.Errorf("fetchVersions() ETags = %q, want one request with \"v1\"", d.etags)
+	}
+
+	// The revalidated listing counts as fetched now and fresh again
+	var stored []JavaRelease
+	info, err := cache.LoadMetadata("versions/fake", &stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.StoredAt.After(storedAt) || !info.Fresh() {
+		t.Errorf("revalidated listing stored at %v, fresh %v; want now and fresh", info.StoredAt, info.Fresh())
+	}
+}
+
+func TestListVersionsChanged(t *testing.T) {
+	useTempCache(t)
+	storeVersions(t, oldListing, cache.MetadataInfo{ETag: `"v1"`})
+	d := &fakeDistributor{answers: []fakeAnswer{{releases: newListing, validators: cache.MetadataInfo{ETag: `"v2"`}}}}
+
+	releases, err := listVersions(context.Background(), d)
+	if err != nil {
+		t.Fatalf("listVersions(): %v", err)
+	}
+	if got := versionList(releases); got != "25,21,17" {
+		t.Errorf("listVersions() = %s, want the new 25,21,17", got)
+	}
+}
+
+func TestListVersionsStaleFallback(t *testing.T) {
+	unreachable := errors.New("API request failed: connection refused")
+	tests := []struct {
+		name    string
+		answer  fakeAnswer
+		wantErr string
+	}{
+		{name: "distributor unreachable", answer: fakeAnswer{err: unreachable}, wantErr: "connection refused"},
+		{name: "empty listing", answer: fakeAnswer{releases: []JavaRelease{}}, wantErr: "Fake listed no versions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempCache(t)
+			storedAt := time.Now().Add(-72 * time.Hour)
+			storeVersions(t, oldListing, cache.MetadataInfo{StoredAt: storedAt, ETag: `"v1"`})
+			d := &fakeDistributor{answers: []fakeAnswer{tt.answer}}
+
+			releases, err := listVersions(context.Background(), d)
+			var stale *StaleVersionsError
+			if !errors.As(err, &stale) {
+				t.Fatalf("listVersions() error = %v, want a StaleVersionsError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "cached 3 days ago") {
+				t.Errorf("listVersions() error = %q, want %q and the cache age", err, tt.wantErr)
+			}
+			if tt.answer.err != nil && !errors.Is(err, tt.answer.err) {
+				t.Errorf("StaleVersionsError does not wrap %v", tt.answer.err)
+			}
+			if !stale.StoredAt.Equal(storedAt) {
+				t.Errorf("StoredAt = %v, want %v", stale.StoredAt, storedAt)
+			}
+			if got := versionList(releases); got != "21,17" {
+				t.Errorf("listVersions() = %s, want the cached 21,17", got)
+			}
+		})
+	}
+}
+
+func TestListVersionsFailsWithoutCache(t *testing.T) {
+	useTempCache(t)
+	unreachable := errors.New("connection refused")
+	d := &fakeDistributor{answers: []fakeAnswer{{err: unreachable}}}
+
+	_, err := listVersions(context.Background(), d)
+	var stale *StaleVersionsError
+	if !errors.Is(err, unreachable) || errors.As(err, &stale) {
+		t.Errorf("listVersions() error = %v, want %v without a cached listing", err, unreachable)
+	}
+}
+
+func TestListVersionsWithoutRevalidation(t *testing.T) {
+	useTempCache(t)
+	storeVersions(t, oldListing, cache.MetadataInfo{ETag: `"v1"`})
+	d := &fakeDistributor{answers: []fakeAnswer{{releases: newListing}}}
+
+	releases, err := listVersions(context.Background(), plainDistributor{d})
+	if err != nil {
+		t.Fatalf("listVersions(): %v", err)
+	}
+	if got := versionList(releases); got != "25,21,17" {
+		t.Errorf("listVersions() = %s, want 25,21,17", got)
+	}
+	if len(d.etags) != 1 || d.etags[0] != "" {
+		t.Errorf("ETags sent = %q, want an unconditional request", d.etags)
+	}
+}
+
+func TestAdoptiumVersionsRevalidated(t *testing.T) {
+	useTempCache(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/info/available_releases" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"listing-1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"listing-1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte(`{"available_lts_releases":[17,21],"available_releases":[17,21,24],"most_recent_feature_release":24}`))
+	}))
+	defer server.Close()
+
+	adoptium := NewAdoptiumDistributor(config.DistributorConfig{APIBaseURLs: []string{server.URL}})
+	for round := 1; round <= 2; round++ {
+		releases, err := listVersions(context.Background(), adoptium)
+		if err != nil {
+			t.Fatalf("listVersions() round %d: %v", round, err)
+		}
+		if got := versionList(releases); got != "24,21,17" {
+			t.Errorf("listVersions() round %d = %s, want 24,21,17", round, got)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("API asked %d times, want twice", requests)
+	}
+
+	// Once the API is gone, the last listing is shown
+	server.Close()
+	releases, err := listVersions(context.Background(), adoptium)
+	var stale *StaleVersionsError
+	if !errors.As(err, &stale) || versionList(releases) != "24,21,17" {
+		t.Errorf("listVersions() without the API = %s, %v; want the cached listing and a StaleVersionsError", versionList(releases), err)
+	}
+}
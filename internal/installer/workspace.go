@@ -0,0 +1,190 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"jv/internal/lockfile"
+)
+
+const (
+	// workspacePrefix names the per-run temp directories holding downloads
+	workspacePrefix = "jv-install-"
+
+	// legacyWorkspace is the fixed temp directory used by older versions
+	legacyWorkspace = "jv-install"
+
+	// ownerFile records the ID of the process using a work directory or lock
+	ownerFile = ".jv-owner"
+
+	// lockSuffix is appended to an installation path to lock it
+	lockSuffix = ".jv-lock"
+
+	// lockWait is how long to wait for another jv process to finish
+	lockWait = 5 * time.Minute
+
+	// orphanAge is how old a work directory without an owner must be before
+	// it is treated as abandoned rather than still being set up
+	orphanAge = time.Hour
+
+	// partialMaxAge is how long interrupted downloads are kept for resuming
+	partialMaxAge = 7 * 24 * time.Hour
+)
+
+// newWorkspace creates a temp directory for one run. It is marked with the
+// process ID so a later run can tell a crashed run's leftovers from a
+// concurrent run's files.
+func newWorkspace() (string, error) {
+	dir, err := os.MkdirTemp(os.TempDir(), workspacePrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	if err := lockfile.WriteOwner(filepath.Join(dir, ownerFile)); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// closeWorkspace removes a run's temp directory. Interrupted downloads are
+// moved aside first so the next run can resume them.
+func closeWorkspace(dir string) {
+	stashPartials(dir)
+	os.RemoveAll(dir)
+}
+
+// partialDir holds interrupted downloads between runs
+func partialDir() string {
+	return filepath.Join(os.TempDir(), "jv-partial")
+}
+
+// adoptPartial moves an interrupted download of the same file left by an
+// earlier run into place, so DownloadFile resumes it
+func adoptPartial(destPath string) {
+	src := filepath.Join(partialDir(), filepath.Base(destPath)+partSuffix)
+	if _, err := os.Stat(src); err == nil {
+		os.Rename(src, destPath+partSuffix)
+	}
+}
+
+// stashPartials keeps the .part files of a workspace for the next run
+func stashPartials(dir string) {
+	parts, _ := filepath.Glob(filepath.Join(dir, "*"+partSuffix))
+	if len(parts) == 0 {
+		return
+	}
+	if err := os.MkdirAll(partialDir(), 0755); err != nil {
+		return
+	}
+	for _, part := range parts {
+		os.Rename(part, filepath.Join(partialDir(), filepath.Base(part)))
+	}
+}
+
+// lockInstallPath takes the lock for an installation path, waiting while
+// another live jv process holds it. Locks left by dead processes are taken over.
+func lockInstallPath(installPath string) (*lockfile.Lock, error) {
+	lock, err := lockfile.Acquire(installPath+lockSuffix, lockWait, func() {
+		fmt.Printf("Waiting for another jv process installing to %s...\n", installPath)
+	})
+	if errors.Is(err, lockfile.ErrLocked) {
+		return nil, fmt.Errorf("%s is %w", installPath, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s: %w", installPath, err)
+	}
+	return lock, nil
+}
+
+// ownerAlive reports whether the process recorded in an owner or lock file is
+// still running. Files too young to have been written yet count as alive.
+func ownerAlive(path string) bool {
+	return lockfile.OwnerAlive(path, orphanAge)
+}
+
+// dirOwnerAlive is ownerAlive for a work directory, which may not contain its
+// owner file yet right after being created
+func dirOwnerAlive(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ownerFile)); err == nil {
+		return ownerAlive(filepath.Join(dir, ownerFile))
+	}
+	info, err := os.Stat(dir)
+	return err == nil && time.Since(info.ModTime()) < orphanAge
+}
+
+// recentlyModified reports whether anything directly inside dir, such as an
+// archive still being downloaded, changed within orphanAge. Writing to a
+// file does not update the modification time of its directory.
+func recentlyModified(dir string) bool {
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) < orphanAge {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupStale removes what crashed or interrupted runs left behind: temp
+// workspaces, staging directories and locks of dead processes, and old
+// partial downloads. A backup left by an interrupted swap is restored if
+// the installation it replaced is missing, and removed otherwise.
+func CleanupStale(installBases []string) {
+	tempDir := os.TempDir()
+
+	// Older jv versions shared one workspace and write no owner file, so it
+	// is only removed once nothing in it has changed for a while; one of them
+	// may still be installing while this version starts
+	legacy := filepath.Join(tempDir, legacyWorkspace)
+	if !dirOwnerAlive(legacy) && !recentlyModified(legacy) {
+		os.RemoveAll(legacy)
+	}
+
+	workspaces, _ := filepath.Glob(filepath.Join(tempDir, workspacePrefix+"*"))
+	for _, dir := range workspaces {
+		if !dirOwnerAlive(dir) {
+			os.RemoveAll(dir)
+		}
+	}
+
+	parts, _ := filepath.Glob(filepath.Join(partialDir(), "*"+partSuffix))
+	for _, part := range parts {
+		if info, err := os.Stat(part); err == nil && time.Since(info.ModTime()) > partialMaxAge {
+			os.Remove(part)
+		}
+	}
+
+	for _, base := range installBases {
+		stagings, _ := filepath.Glob(filepath.Join(base, stagingPrefix+"*"))
+		for _, dir := range stagings {
+			if !dirOwnerAlive(dir) {
+				os.RemoveAll(dir)
+			}
+		}
+
+		locks, _ := filepath.Glob(filepath.Join(base, "*"+lockSuffix))
+		for _, lock := range locks {
+			if !ownerAlive(lock) {
+				os.Remove(lock)
+			}
+		}
+
+		backups, _ := filepath.Glob(filepath.Join(base, "*"+backupSuffix))
+		for _, backup := range backups {
+			installPath := strings.TrimSuffix(backup, backupSuffix)
+			if _, err := os.Stat(installPath + lockSuffix); err == nil {
+				// A live process is in the middle of swapping
+				continue
+			}
+			if _, err := os.Stat(installPath); os.IsNotExist(err) {
+				os.Rename(backup, installPath)
+			} else {
+				os.RemoveAll(backup)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package installer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// deadPID returns the ID of a process that has already exited
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run a short-lived process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+// writeFile creates path with content, backdated by age
+func writeFile(t *testing.T, path string, content string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, path, age)
+}
+
+// backdate sets the modification time of path to age ago
+func backdate(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("%s is missing: %v", filepath.Base(path), err)
+	}
+}
+
+// useTempDir points os.TempDir at dir for the rest of the test
+func useTempDir(t *testing.T, dir string) {
+	t.Setenv("TMPDIR", dir)
+	t.Setenv("TMP", dir)
+	t.Setenv("TEMP", dir)
+}
+
+func TestLockInstallPathTakesOverStaleLock(t *testing.T) {
+	installPath := filepath.Join(t.TempDir(), "jdk-21")
+	writeFile(t, installPath+lockSuffix, strconv.Itoa(deadPID(t)), 0)
+
+	lock, err := lockInstallPath(installPath)
+	if err != nil {
+		t.Fatalf("lockInstallPath() with a dead owner: %v", err)
+	}
+	data, _ := os.ReadFile(installPath + lockSuffix)
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file holds %q after takeover, want %d", data, os.Getpid())
+	}
+
+	lock.Unlock()
+	assertMissing(t, installPath+lockSuffix)
+}
+
+func TestOwnerAlive(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		age     time.Duration
+		want    bool
+	}{
+		{name: "current process", content: strconv.Itoa(os.Getpid()), want: true},
+		{name: "exited process", content: strconv.Itoa(deadPID(t)), want: false},
+		{name: "not written yet", content: "", age: time.Minute, want: true},
+		{name: "never written", content: "", age: orphanAge + time.Minute, want: false},
+		{name: "garbage", content: "owner", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			writeFile(t, path, tt.content, tt.age)
+			if got := ownerAlive(path); got != tt.want {
+				t.Errorf("ownerAlive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if ownerAlive(filepath.Join(dir, "missing")) {
+		t.Error("ownerAlive() of a missing file = true, want false")
+	}
+}
+
+func TestCleanupStaleInstallBase(t *testing.T) {
+	useTempDir(t, t.TempDir())
+	base := t.TempDir()
+	dead := strconv.Itoa(deadPID(t))
+	live := strconv.Itoa(os.Getpid())
+
+	// Interrupted swap whose installation is missing: restored
+	writeRelease(t, filepath.Join(base, "jdk-21"+backupSuffix), "21")
+	// Interrupted swap whose new installation is in place: removed
+	writeRelease(t, filepath.Join(base, "jdk-17"), "17 new")
+	writeRelease(t, filepath.Join(base, "jdk-17"+backupSuffix), "17 old")
+	// Swap in progress in a live process: left alone
+	writeRelease(t, filepath.Join(base, "jdk-11"+backupSuffix), "11")
+	writeFile(t, filepath.Join(base, "jdk-11"+lockSuffix), live, 0)
+	// Lock of a crashed process
+	writeFile(t, filepath.Join(base, "jdk-8"+lockSuffix), dead, 0)
+	// Staging directories of a crashed and a running install
+	writeFile(t, filepath.Join(base, stagingPrefix+"dead", ownerFile), dead, 0)
+	writeFile(t, filepath.Join(base, stagingPrefix+"live", ownerFile), live, 0)
+
+	CleanupStale([]string{base})
+
+	if got := readRelease(t, filepath.Join(base, "jdk-21")); got != "21" {
+		t.Errorf("jdk-21 holds %q, want the restored backup", got)
+	}
+	assertMissing(t, filepath.Join(base, "jdk-21"+backupSuffix))
+
+	if got := readRelease(t, filepath.Join(base, "jdk-17")); got != "17 new" {
+		t.Errorf("jdk-17 holds %q, want the new installation", got)
+	}
+	assertMissing(t, filepath.Join(base, "jdk-17"+backupSuffix))
+
+	assertExists(t, filepath.Join(base, "jdk-11"+backupSuffix))
+	assertMissing(t, filepath.Join(base, "jdk-11"))
+	assertExists(t, filepath.Join(base, "jdk-11"+lockSuffix))
+
+	assertMissing(t, filepath.Join(base, "jdk-8"+lockSuffix))
+	assertMissing(t, filepath.Join(base, stagingPrefix+"dead"))
+	assertExists(t, filepath.Join(base, stagingPrefix+"live"))
+}
+
+func TestCleanupStaleTempDir(t *testing.T) {
+	tempDir := t.TempDir()
+	useTempDir(t, tempDir)
+	dead := strconv.Itoa(deadPID(t))
+
+	writeFile(t, filepath.Join(tempDir, workspacePrefix+"dead", ownerFile), dead, 0)
+	writeFile(t, filepath.Join(tempDir, workspacePrefix+"live", ownerFile), strconv.Itoa(os.Getpid()), 0)
+	// Just created by a concurrent run, which has not written its owner yet
+	if err := os.Mkdir(filepath.Join(tempDir, workspacePrefix+"new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(partialDir(), "old.zip"+partSuffix), "old", partialMaxAge+time.Hour)
+	writeFile(t, filepath.Join(partialDir(), "recent.zip"+partSuffix), "recent", time.Hour)
+
+	CleanupStale(nil)
+
+	assertMissing(t, filepath.Join(tempDir, workspacePrefix+"dead"))
+	assertExists(t, filepath.Join(tempDir, workspacePrefix+"live"))
+	assertExists(t, filepath.Join(tempDir, workspacePrefix+"new"))
+	assertMissing(t, filepath.Join(partialDir(), "old.zip"+partSuffix))
+	assertExists(t, filepath.Join(partialDir(), "recent.zip"+partSuffix))
+}
+
+func TestCleanupStaleLegacyWorkspace(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileAge     time.Duration
+		dirAge      time.Duration
+		wantRemoved bool
+	}{
+		// An older jv may still be downloading into it
+		{name: "recent download", fileAge: time.Minute, dirAge: orphanAge + time.Hour, wantRemoved: false},
+		{name: "recently created", fileAge: orphanAge + time.Hour, dirAge: time.Minute, wantRemoved: false},
+		{name: "abandoned", fileAge: orphanAge + time.Hour, dirAge: orphanAge + time.Hour, wantRemoved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			useTempDir(t, tempDir)
+
+			legacy := filepath.Join(tempDir, legacyWorkspace)
+			writeFile(t, filepath.Join(legacy, "jdk-21.zip"), "archive", tt.fileAge)
+			backdate(t, legacy, tt.dirAge)
+
+			CleanupStale(nil)
+
+			if tt.wantRemoved {
+				assertMissing(t, legacy)
+			} else {
+				assertExists(t, filepath.Join(legacy, "jdk-21.zip"))
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"jv/internal/env"
+)
+
+// pollInterval is how often a held lock is checked again
+const pollInterval = 100 * time.Millisecond
+
+// ErrLocked is returned when another live jv process still holds a lock
+// after the wait
+var ErrLocked = errors.New("locked by another jv process")
+
+// Lock is a lock file recording the ID of the process that holds it, so
+// that locks left by a crashed process can be told apart and taken over
+type Lock struct {
+	path string
+}
+
+// Acquire creates the lock file at path, waiting up to wait while another
+// live jv process holds it. Locks of dead processes are taken over, as are
+// locks that still have no process ID after wait. onWait, if not nil, is
+// called once when Acquire starts waiting.
+func Acquire(path string, wait time.Duration, onWait func()) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+	waiting := false
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, werr := f.WriteString(strconv.Itoa(os.Getpid()))
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				os.Remove(path)
+				return nil, errors.Join(werr, cerr)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if !OwnerAlive(path, wait) {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w (remove %s if that is wrong)", ErrLocked, path)
+		}
+		if !waiting && onWait != nil {
+			onWait()
+		}
+		waiting = true
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() {
+	os.Remove(l.path)
+}
+
+// WriteOwner records the current process ID in path
+func WriteOwner(path string) error {
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+}
+
+// OwnerAlive reports whether the process recorded in an owner or lock file
+// is still running. Files younger than grace without a process ID may not
+// have been written yet and count as alive.
+func OwnerAlive(path string, grace time.Duration) bool {
+	data, err := os.ReadFile(path)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		info, statErr := os.Stat(path)
+		return statErr == nil && time.Since(info.ModTime()) < grace
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+	return env.ProcessAlive(pid)
+}
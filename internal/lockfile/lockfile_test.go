@@ -0,0 +1,140 @@
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// deadPID returns the ID of a process that has already exited
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run a short-lived process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json.lock")
+
+	lock, err := Acquire(path, time.Second, nil)
+	if err != nil {
+		t.Fatalf("Acquire(): %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("lock file not created: %v", err)
+	}
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file holds %q, want the process ID %d", data, os.Getpid())
+	}
+
+	lock.Unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file still exists after Unlock: %v", err)
+	}
+}
+
+func TestAcquireTakesOverDeadOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json.lock")
+	if err := os.WriteFile(path, []byte(strconv.Itoa(deadPID(t))), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	waited := false
+	lock, err := Acquire(path, time.Second, func() { waited = true })
+	if err != nil {
+		t.Fatalf("Acquire() with a dead owner: %v", err)
+	}
+	defer lock.Unlock()
+
+	if waited {
+		t.Error("Acquire() waited for a dead owner")
+	}
+	if data, _ := os.ReadFile(path); string(data) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file holds %q after takeover, want %d", data, os.Getpid())
+	}
+}
+
+func TestAcquireTakesOverUnparsableLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json.lock")
+	if err := os.WriteFile(path, []byte("not a pid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Acquire(path, time.Second, nil)
+	if err != nil {
+		t.Fatalf("Acquire() with an unparsable lock: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestAcquireWaitsForLiveOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json.lock")
+	held, err := Acquire(path, time.Second, nil)
+	if err != nil {
+		t.Fatalf("Acquire(): %v", err)
+	}
+
+	waited := false
+	_, err = Acquire(path, 300*time.Millisecond, func() { waited = true })
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Acquire() of a held lock error = %v, want ErrLocked", err)
+	}
+	if !waited {
+		t.Error("onWait was not called while waiting")
+	}
+
+	// Released while the second caller waits
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		held.Unlock()
+	}()
+	lock, err := Acquire(path, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("Acquire() after the owner released the lock: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestOwnerAlive(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "current process", path: write("live", strconv.Itoa(os.Getpid()), 0), want: true},
+		{name: "exited process", path: write("dead", strconv.Itoa(deadPID(t)), 0), want: false},
+		{name: "empty and young", path: write("young", "", time.Second), want: true},
+		{name: "empty and old", path: write("old", "", time.Hour), want: false},
+		{name: "not a number", path: write("garbage", "pid", 0), want: false},
+		{name: "missing", path: filepath.Join(dir, "missing"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OwnerAlive(tt.path, time.Minute); got != tt.want {
+				t.Errorf("OwnerAlive(%s) = %v, want %v", filepath.Base(tt.path), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"jv/internal/httpclient"
+
+	"github.com/creativeprojects/go-selfupdate"
+	"github.com/google/go-github/v30/github"
+	"golang.org/x/oauth2"
+)
+
+// githubSource loads jv releases from GitHub through the shared HTTP client,
+// so proxy and CA bundle settings apply to update checks and downloads
+type githubSource struct {
+	api    *github.Client
+	client *http.Client
+}
+
+// newGitHubSource creates a release source, authenticating with $GITHUB_TOKEN when set
+func newGitHubSource() (*githubSource, error) {
+	client, err := httpclient.Client(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		client.Transport = &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+			Base:   client.Transport,
+		}
+	}
+
+	api := github.NewClient(client)
+	api.UserAgent = httpclient.UserAgent
+
+	return &githubSource{api: api, client: client}, nil
+}
+
+// ListReleases returns all releases of the repository
+func (s *githubSource) ListReleases(ctx context.Context, repository selfupdate.Repository) ([]selfupdate.SourceRelease, error) {
+	owner, repo, err := repository.GetSlug()
+	if err != nil {
+		return nil, err
+	}
+
+	rels, res, err := s.api.Repositories.ListReleases(ctx, owner, repo, nil)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			// Repository or release not found is not an error here
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	releases := make([]selfupdate.SourceRelease, len(rels))
+	for i, rel := range rels {
+		releases[i] = selfupdate.NewGitHubRelease(rel)
+	}
+	return releases, nil
+}
+
+// DownloadReleaseAsset downloads an asset of a jv release
+func (s *githubSource) DownloadReleaseAsset(ctx context.Context, rel *selfupdate.Release, assetID int64) (io.ReadCloser, error) {
+	if rel == nil {
+		return nil, selfupdate.ErrInvalidRelease
+	}
+
+	owner, repo, err := selfupdate.ParseSlug(GitHubRepo).GetSlug()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, _, err := s.api.Repositories.DownloadReleaseAsset(ctx, owner, repo, assetID, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download release asset %d: %w", assetID, err)
+	}
+	return rc, nil
+}
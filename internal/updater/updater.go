@@ -32,8 +32,14 @@ type Updater struct {
 
 // NewUpdater creates a new Updater instance
 func NewUpdater(cfg *config.Config, version string) (*Updater, error) {
+	source, err := newGitHubSource()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updater: %w", err)
+	}
+
 	// Configure selfupdate with SHA256 checksum validation
 	su, err := selfupdate.NewUpdater(selfupdate.Config{
+		Source: source,
 		Validator: &selfupdate.ChecksumValidator{
 			UniqueFilename: "SHA256SUMS.txt",
 		},
@@ -112,7 +118,7 @@ func (u *Updater) PerformUpdate(ctx context.Context, release *selfupdate.Release
 	}
 
 	// Perform the update
-	if err := selfupdate.UpdateTo(ctx, release.AssetURL, release.AssetName, exe); err != nil {
+	if err := u.selfUpdater.UpdateTo(ctx, release, exe); err != nil {
 		// Attempt rollback
 		if rollbackErr := os.Rename(backup, exe); rollbackErr != nil {
 			return fmt.Errorf("update failed and rollback failed: update error: %w, rollback error: %v", err, rollbackErr)
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"jv/internal/config"
+)
+
+// jdkPaths lists the paths of jdks, for comparing selections
+func jdkPaths(jdks []config.InstalledJDK) string {
+	var paths []string
+	for _, jdk := range jdks {
+		paths = append(paths, filepath.Base(jdk.Path))
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestFindInstalledJDKs(t *testing.T) {
+	base := t.TempDir()
+	cfg := &config.Config{InstalledJDKs: []config.InstalledJDK{
+		{Version: "21", Release: "21.0.5+11", Path: filepath.Join(base, "jdk-21.0.5+11")},
+		{Version: "21", Release: "21.0.4+7", Path: filepath.Join(base, "jdk-21.0.4+7")},
+		{Version: "17", Release: "17.0.13+11", Path: filepath.Join(base, "jdk-17.0.13+11")},
+		{Version: "11", Release: "11.0.25+9", Path: filepath.Join(base, "jdk-11.0.25+9")},
+		{Version: "1", Path: filepath.Join(base, "jdk-1")},
+	}}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     string
+	}{
+		{name: "major version", selector: "21", want: "jdk-21.0.5+11,jdk-21.0.4+7"},
+		{name: "exact release", selector: "21.0.4+7", want: "jdk-21.0.4+7"},
+		{name: "partial release", selector: "21.0.5", want: "jdk-21.0.5+11"},
+		// An exact version must not also select 11, 17 and 21
+		{name: "exact match wins", selector: "1", want: "jdk-1"},
+		{name: "substring", selector: "0.13", want: "jdk-17.0.13+11"},
+		{name: "path", selector: filepath.Join(base, "jdk-17.0.13+11"), want: "jdk-17.0.13+11"},
+		{name: "path not cleaned", selector: filepath.Join(base, "jdk-11.0.25+9") + string(filepath.Separator), want: "jdk-11.0.25+9"},
+		{name: "path in other case", selector: strings.ToUpper(filepath.Join(base, "jdk-11.0.25+9")), want: "jdk-11.0.25+9"},
+		{name: "no match", selector: "8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jdkPaths(findInstalledJDKs(cfg, tt.selector)); got != tt.want {
+				t.Errorf("findInstalledJDKs(%q) = %q, want %q", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectPruneCandidates(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	recent := now.Add(-(10*24 + 1) * time.Hour).Format(time.RFC3339)
+	old := now.Add(-(200*24 + 1) * time.Hour).Format(time.RFC3339)
+
+	jdk := func(name string, version string, release string, installedAt string) config.InstalledJDK {
+		return config.InstalledJDK{Version: version, Release: release, Path: filepath.Join(base, name), Distributor: "Eclipse Adoptium", Scope: "user", InstalledAt: installedAt}
+	}
+	jre := jdk("jre-21.0.4+7", "21", "21.0.4+7", recent)
+	jre.ImageType = "jre"
+	system := jdk("system-jdk-21.0.2+13", "21", "21.0.2+13", recent)
+	system.Scope = "system"
+	earlyAccess := jdk("jdk-21.0.6-ea+1", "21", "21.0.6-ea+1", recent)
+	earlyAccess.EarlyAccess = true
+	corp := jdk("corp-jdk-21.0.1+12", "21", "21.0.1+12", recent)
+	corp.Distributor = "Corp JDK"
+
+	installed := []config.InstalledJDK{
+		jdk("jdk-21.0.5+11", "21", "21.0.5+11", recent),
+		jdk("jdk-21.0.4+7", "21", "21.0.4+7", recent),
+		jdk("jdk-21.0.3+9", "21", "21.0.3+9", recent), // JAVA_HOME
+		jdk("jdk-21.0.2+13", "21", "", recent),        // Release read from the release file
+		jre,
+		system,
+		earlyAccess,
+		corp,
+		jdk("jdk-17.0.13+11", "17", "17.0.13+11", old),
+		jdk("jdk-11.0.25+9", "11", "11.0.25+9", old), // Switched to recently
+		jdk("jdk-8u432-b06", "8", "8u432-b06", ""),   // Install time unknown
+		jdk("jdk-23.0.1+11", "23", "23.0.1+11", recent),
+	}
+	for _, j := range installed {
+		if err := os.MkdirAll(j.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(base, "jdk-21.0.2+13", "release"), []byte(`JAVA_RUNTIME_VERSION="21.0.2+13-LTS"`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(base, "jdk-23.0.1+11")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		InstalledJDKs: installed,
+		CustomPaths:   []string{filepath.Join(base, "jdk-21.0.5+11"), filepath.Join(base, "custom-gone"), filepath.Join(base, "jdk-23.0.1+11")},
+		SearchPaths:   []string{base, filepath.Join(base, "search-gone")},
+		SwitchHistory: []config.SwitchRecord{
+			{Path: filepath.Join(base, "jdk-11.0.25+9"), SwitchedAt: now.AddDate(0, 0, -300)},
+			{Path: filepath.Join(base, "jdk-11.0.25+9"), SwitchedAt: now.AddDate(0, 0, -5)},
+		},
+	}
+
+	tests := []struct {
+		name string
+		days int
+		want []string
+	}{
+		{
+			name: "default days",
+			days: pruneDefaultDays,
+			want: []string{
+				"custom path custom-gone: folder missing",
+				"installation jdk-17.0.13+11: unused for 200 days",
+				"installation jdk-21.0.2+13: superseded by 21.0.5+11",
+				"installation jdk-21.0.4+7: superseded by 21.0.5+11",
+				"installed JDK jdk-23.0.1+11: folder missing",
+				"search path search-gone: folder missing",
+			},
+		},
+		{
+			name: "fewer days",
+			days: 7,
+			want: []string{
+				"custom path custom-gone: folder missing",
+				"installation corp-jdk-21.0.1+12: unused for 10 days",
+				"installation jdk-17.0.13+11: unused for 200 days",
+				"installation jdk-21.0.2+13: superseded by 21.0.5+11, unused for 10 days",
+				"installation jdk-21.0.4+7: superseded by 21.0.5+11, unused for 10 days",
+				"installation jdk-21.0.5+11: unused for 10 days",
+				"installation jdk-21.0.6-ea+1: unused for 10 days",
+				"installation jre-21.0.4+7: unused for 10 days",
+				"installation system-jdk-21.0.2+13: unused for 10 days",
+				"installed JDK jdk-23.0.1+11: folder missing",
+				"search path search-gone: folder missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range collectPruneCandidates(cfg, tt.days, filepath.Join(base, "jdk-21.0.3+9")) {
+				got = append(got, c.kind+" "+filepath.Base(c.path)+": "+c.reason)
+				if (c.kind == "installation") != (c.jdk != nil) {
+					t.Errorf("%s %s has jdk %v", c.kind, c.path, c.jdk)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("collectPruneCandidates() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}